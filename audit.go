@@ -0,0 +1,126 @@
+package gokenall
+
+import (
+	"encoding/csv"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	auditMaxSamples = 3
+	auditKeywordExp = `(第|丁目|番地|番|号|地割|階|を除く|以上|以下|以降)`
+	auditNumberExp  = `[` + numeralChars + `]+`
+	auditOtherExp   = `[^「」（）～－、・]+`
+)
+
+var (
+	auditKeywordReg = regexp.MustCompile(auditKeywordExp)
+	auditNumberReg  = regexp.MustCompile(auditNumberExp)
+	auditOtherReg   = regexp.MustCompile(auditOtherExp)
+)
+
+// Audit records the inputs whose parenthetical in the street name is not fully handled at normalize.
+// The inputs are grouped by the shape of the parenthetical like `N－N、N番地`.
+type Audit struct {
+	entries map[string]*AuditEntry
+}
+
+// AuditEntry is a group of the inputs which have the same shape of the parenthetical.
+type AuditEntry struct {
+	Shape   string          // 括弧内の形 (数字は N、その他の語は X に置き換える)
+	Count   int             // 該当した入力の件数
+	Samples []*JapanZipCode // 該当した入力の例 (最大3件)
+}
+
+// NewAudit creates empty Audit.
+func NewAudit() *Audit {
+	return &Audit{
+		entries: make(map[string]*AuditEntry),
+	}
+}
+
+func (audit *Audit) record(p *JapanZipCode, innerBless string) {
+	shape := auditShape(innerBless)
+	entry, ok := audit.entries[shape]
+	if !ok {
+		entry = &AuditEntry{Shape: shape}
+		audit.entries[shape] = entry
+	}
+	entry.Count++
+	if len(entry.Samples) < auditMaxSamples {
+		sample := *p
+		entry.Samples = append(entry.Samples, &sample)
+	}
+}
+
+// Entries returns the recorded groups in descending order of the count.
+func (audit *Audit) Entries() []*AuditEntry {
+	entries := make([]*AuditEntry, 0, len(audit.entries))
+	for _, entry := range audit.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Shape < entries[j].Shape
+	})
+	return entries
+}
+
+// WriteCSV writes the recorded groups to w as UTF-8 csv texts.
+// Each sample is written in one line with the shape and the count of the group.
+func (audit *Audit) WriteCSV(w io.Writer) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write([]string{"shape", "count", "zip_code", "pref", "city", "street"}); err != nil {
+		return errors.Wrap(err, "failed to write audit header")
+	}
+	for _, entry := range audit.Entries() {
+		for _, sample := range entry.Samples {
+			if err := csvWriter.Write([]string{entry.Shape, strconv.Itoa(entry.Count), sample.ZipCode, sample.Pref, sample.City, sample.Street}); err != nil {
+				return errors.Wrapf(err, "failed to write audit entry: %s", entry.Shape)
+			}
+		}
+	}
+	csvWriter.Flush()
+	return errors.Wrap(csvWriter.Error(), "failed to flush audit")
+}
+
+// auditShape makes the shape of the parenthetical.
+// The numbers are replaced with N, the words except keywords are replaced with X,
+// and the same items repeated in a list are collapsed into one.
+func auditShape(innerBless string) string {
+	shape := auditNumberReg.ReplaceAllString(innerBless, "N")
+	shape = auditOtherReg.ReplaceAllStringFunc(shape, func(s string) string {
+		words := auditKeywordReg.Split(s, -1)
+		keywords := auditKeywordReg.FindAllString(s, -1)
+		var b strings.Builder
+		for i, word := range words {
+			switch {
+			case word == "":
+			case strings.Trim(word, "N") == "":
+				b.WriteString("N")
+			default:
+				b.WriteString("X")
+			}
+			if i < len(keywords) {
+				b.WriteString(keywords[i])
+			}
+		}
+		return b.String()
+	})
+
+	items := strings.Split(shape, "、")
+	collapsed := items[:1]
+	for _, item := range items[1:] {
+		if item != collapsed[len(collapsed)-1] {
+			collapsed = append(collapsed, item)
+		}
+	}
+	return strings.Join(collapsed, "、")
+}
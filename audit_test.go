@@ -0,0 +1,59 @@
+package gokenall
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_auditShape(t *testing.T) {
+	type args struct {
+		innerBless string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"", args{"８８－２、２７１－１０、３４３－２、８１４、１７５２番地"}, "N－N、N、N番地"},
+		{"", args{"１丁目「１１を除く」"}, "N丁目「Nを除く」"},
+		{"", args{"第４０地割「５７番地１２５、１７６を除く」～第４５地割"}, "第N地割「N番地N、Nを除く」～第N地割"},
+		{"", args{"大字上勇知、下勇知"}, "X"},
+		{"", args{"１～１９丁目"}, "N～N丁目"},
+		{"", args{"第一地割～第十五地割"}, "第N地割～第N地割"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := auditShape(tt.args.innerBless); got != tt.want {
+				t.Errorf("auditShape() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAudit_WriteCSV(t *testing.T) {
+	inputs := []string{
+		`01224,"066  ","0660005","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","ｷｮｳﾜ(88-2､271-10ﾊﾞﾝﾁ)","北海道","千歳市","協和（８８－２、２７１－１０番地）",1,0,0,0,0,0`,
+		`01224,"066  ","0660006","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","ｷｮｳﾜ(1-2､3-4､5-6ﾊﾞﾝﾁ)","北海道","千歳市","協和（１－２、３－４、５－６番地）",1,0,0,0,0,0`,
+		`04101,"980  ","9800065","ﾐﾔｷﾞｹﾝ","ｾﾝﾀﾞｲｼｱｵﾊﾞｸ","ﾂﾁﾄｲ(1ﾁｮｳﾒ<11ｦﾉｿﾞｸ>)","宮城県","仙台市青葉区","土樋（１丁目「１１を除く」）",0,0,1,0,0,0`,
+		`26103,"606  ","6060017","ｷｮｳﾄﾌ","ｷｮｳﾄｼｻｷｮｳｸ","ｲﾜｸﾗｱｸﾞﾗﾁｮｳ(ｿﾉﾀ)","京都府","京都市左京区","岩倉上蔵町（その他）",1,0,0,0,0,0`,
+	}
+	audit := NewAudit()
+	normer := newNormalizer()
+	normer.audit = audit
+	for _, input := range inputs {
+		p, _ := parseCSV(input, false)
+		normer.push(p)
+	}
+
+	want := "shape,count,zip_code,pref,city,street\n" +
+		"N－N、N－N番地,2,0660005,北海道,千歳市,協和（８８－２、２７１－１０番地）\n" +
+		"N－N、N－N番地,2,0660006,北海道,千歳市,協和（１－２、３－４、５－６番地）\n" +
+		"N丁目「Nを除く」,1,9800065,宮城県,仙台市青葉区,土樋（１丁目「１１を除く」）\n"
+	var buf bytes.Buffer
+	if err := audit.WriteCSV(&buf); err != nil {
+		t.Fatalf("Audit.WriteCSV() error = %v", err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("Audit.WriteCSV() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package gokenall
+
+import (
+	"sort"
+	"strconv"
+)
+
+// BuildingIndex is an index of high-rise buildings which have zip codes for each floor.
+// Build it from the list parsed with NormalizeBuilding option.
+// The names are matched by SearchKey, so `霞が関` finds `霞ヶ関` as well.
+type BuildingIndex struct {
+	floors map[buildingKey][]*JapanZipCode
+}
+
+type buildingKey struct {
+	pref     string
+	city     string
+	building string
+}
+
+// NewBuildingIndex creates BuildingIndex from the list.
+// The rows without Building are ignored.
+func NewBuildingIndex(list []*JapanZipCode) *BuildingIndex {
+	idx := &BuildingIndex{
+		floors: make(map[buildingKey][]*JapanZipCode),
+	}
+	for _, p := range list {
+		if p.Building == "" {
+			continue
+		}
+		key := buildingKey{SearchKey(p.Pref), SearchKey(p.City), SearchKey(p.Building)}
+		idx.floors[key] = append(idx.floors[key], p)
+	}
+	for _, floors := range idx.floors {
+		sort.SliceStable(floors, func(i, j int) bool {
+			return floorOrder(floors[i].Floor) < floorOrder(floors[j].Floor)
+		})
+	}
+	return idx
+}
+
+// Buildings returns names of all buildings in the city.
+func (idx *BuildingIndex) Buildings(pref, city string) []string {
+	buildings := []string{}
+	pref, city = SearchKey(pref), SearchKey(city)
+	for key, floors := range idx.floors {
+		if key.pref == pref && key.city == city {
+			buildings = append(buildings, floors[0].Building)
+		}
+	}
+	sort.Strings(buildings)
+	return buildings
+}
+
+// Floors returns all floors of the building in ascending order.
+// The row of FloorUnknown comes first.
+func (idx *BuildingIndex) Floors(pref, city, building string) []*JapanZipCode {
+	return idx.floors[buildingKey{SearchKey(pref), SearchKey(city), SearchKey(building)}]
+}
+
+func floorOrder(floor string) int {
+	i, err := strconv.Atoi(floor)
+	if err != nil {
+		return -1
+	}
+	return i
+}
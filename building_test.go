@@ -0,0 +1,51 @@
+package gokenall
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildingIndex_Floors(t *testing.T) {
+	f1 := &JapanZipCode{ZipCode: "1630601", Pref: "東京都", City: "新宿区", Building: "新宿センタービル", Floor: "1"}
+	f2 := &JapanZipCode{ZipCode: "1630602", Pref: "東京都", City: "新宿区", Building: "新宿センタービル", Floor: "2"}
+	f10 := &JapanZipCode{ZipCode: "1630610", Pref: "東京都", City: "新宿区", Building: "新宿センタービル", Floor: "10"}
+	fu := &JapanZipCode{ZipCode: "1630690", Pref: "東京都", City: "新宿区", Building: "新宿センタービル", Floor: FloorUnknown}
+	other := &JapanZipCode{ZipCode: "1600023", Pref: "東京都", City: "新宿区", Street: "西新宿"}
+
+	idx := NewBuildingIndex([]*JapanZipCode{other, f10, f2, fu, f1})
+
+	type args struct {
+		pref     string
+		city     string
+		building string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []*JapanZipCode
+	}{
+		{"sorted", args{"東京都", "新宿区", "新宿センタービル"}, []*JapanZipCode{fu, f1, f2, f10}},
+		{"not found", args{"東京都", "新宿区", "西新宿"}, nil},
+		{"search key", args{"東京都", "新宿区", "新宿 センタービル"}, []*JapanZipCode{fu, f1, f2, f10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idx.Floors(tt.args.pref, tt.args.city, tt.args.building); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildingIndex.Floors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildingIndex_Buildings(t *testing.T) {
+	idx := NewBuildingIndex([]*JapanZipCode{
+		{Pref: "東京都", City: "新宿区", Building: "新宿センタービル", Floor: "1"},
+		{Pref: "東京都", City: "新宿区", Building: "新宿アイランドタワー", Floor: "1"},
+		{Pref: "東京都", City: "新宿区", Building: "新宿センタービル", Floor: "2"},
+		{Pref: "大阪府", City: "大阪市阿倍野区", Building: "あべのハルカス", Floor: "1"},
+	})
+	want := []string{"新宿アイランドタワー", "新宿センタービル"}
+	if got := idx.Buildings("東京都", "新宿区"); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildingIndex.Buildings() = %v, want %v", got, want)
+	}
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/oirik/gokenall"
@@ -31,39 +35,91 @@ func main() {
 	normalize := &normalizeCommand{}
 	gosubcommand.Register("normalize", normalize)
 
+	explain := &explainCommand{}
+	gosubcommand.Register("explain", explain)
+
+	sync := &syncCommand{}
+	gosubcommand.Register("sync", sync)
+
 	os.Exit(int(gosubcommand.Execute()))
 }
 
 type downloadCommand struct {
-	extract bool
-	output  string
+	extract  bool
+	output   string
+	cache    string
+	entry    string
+	minRows  int
+	manifest bool
+	retries  int
+	source   string
+	archive  string
 }
 
+// exitCodeNotModified is the exit status when the data is not updated, distinct from errors.
+const exitCodeNotModified gosubcommand.ExitCode = 3
+
 func (download *downloadCommand) Summary() string {
 	return "Download ken_all.zip from japanpost website"
 }
 
 func (download *downloadCommand) SetFlag(fs *flag.FlagSet) {
 	fs.BoolVar(&download.extract, "x", false, "Extract file from an archive.")
-	fs.StringVar(&download.output, "o", "", "Save file to <string> path instead of standard output.")
+	fs.StringVar(&download.output, "o", "", "Save file to <string> path instead of standard output. The file is replaced only when downloaded successfully.")
+	fs.StringVar(&download.source, "source", "", "Read archive from <string> instead of japanpost website. (url like https://mirror/ken_all/ or file:///mnt/mirror, directory or zip file path)")
+	fs.StringVar(&download.archive, "archive", "", "Download the archive named <string> like add_1909.zip. (default: ken_all.zip)")
+	fs.StringVar(&download.entry, "entry", "", "Extract the file named <string> from an archive. (default: KEN_ALL.CSV or the only one file)")
+	fs.StringVar(&download.cache, "cache", "", "Cache the downloaded file in <string> directory and download only when modified. Exit status 3 if not modified.")
+	fs.IntVar(&download.minRows, "min-rows", 0, "Verify the extracted file is in ken_all format and has <int> rows at least.")
+	fs.IntVar(&download.retries, "retries", 0, "Retry <int> times with backoff on network errors and 429/5xx status.")
+	fs.BoolVar(&download.manifest, "manifest", false, "Write SHA-256 of the output file to <output>.sha256. Requires -o.")
 }
 
 func (download *downloadCommand) Execute(fs *flag.FlagSet) gosubcommand.ExitCode {
-	var w io.Writer
-
-	if download.output == "" || download.output == "-" {
-		w = os.Stdout
-	} else {
-		f, err := os.Create(download.output)
+	opts := &gokenall.DownloadOptions{
+		Extract:  download.extract,
+		CacheDir: download.cache,
+		Entry:    download.entry,
+		MinRows:  download.minRows,
+		Archive:  download.archive,
+	}
+	if download.source != "" {
+		src, err := gokenall.ParseSource(download.source)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, errors.Wrapf(err, "failed to create file: %s", download.output))
-			return gosubcommand.ExitCodeError
+			fmt.Fprintln(os.Stderr, err)
+			return gosubcommand.ExitCodeUsage
 		}
-		defer f.Close()
-		w = f
+		opts.Source = src
+	}
+	if download.retries > 0 {
+		retry := gokenall.DefaultRetryPolicy
+		retry.Attempts = download.retries + 1
+		opts.Retry = &retry
+	}
+	var bar *progressBar
+	if isTerminal(os.Stderr) {
+		bar = &progressBar{w: os.Stderr}
+		opts.Progress = bar.Update
 	}
 
-	if err := gokenall.Download(w, download.extract); err != nil {
+	var err error
+	if download.output == "" || download.output == "-" {
+		if download.manifest {
+			fmt.Fprintln(os.Stderr, "-manifest requires -o")
+			return gosubcommand.ExitCodeUsage
+		}
+		err = gokenall.DownloadWithOptions(os.Stdout, opts)
+	} else {
+		err = gokenall.DownloadFile(download.output, opts, download.manifest)
+	}
+	if bar != nil {
+		bar.Done()
+	}
+	if err == gokenall.ErrNotModified {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeNotModified
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return gosubcommand.ExitCodeError
 	}
@@ -71,64 +127,191 @@ func (download *downloadCommand) Execute(fs *flag.FlagSet) gosubcommand.ExitCode
 	return gosubcommand.ExitCodeSuccess
 }
 
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressBar draws the progress of download in a line.
+type progressBar struct {
+	w       io.Writer
+	drawn   bool
+	percent int
+}
+
+func (bar *progressBar) Update(received, total int64) {
+	if total <= 0 {
+		fmt.Fprintf(bar.w, "\r%.1fMB", float64(received)/(1<<20))
+		bar.drawn = true
+		return
+	}
+	percent := int(received * 100 / total)
+	if bar.drawn && percent == bar.percent {
+		return
+	}
+	const width = 40
+	filled := width * percent / 100
+	fmt.Fprintf(bar.w, "\r[%s%s] %3d%% %.1f/%.1fMB",
+		strings.Repeat("=", filled), strings.Repeat(" ", width-filled), percent,
+		float64(received)/(1<<20), float64(total)/(1<<20))
+	bar.drawn, bar.percent = true, percent
+}
+
+// Done ends the line of the progress bar.
+func (bar *progressBar) Done() {
+	if bar.drawn {
+		fmt.Fprintln(bar.w)
+	}
+}
+
 type updatedCommand struct {
 	print bool
+	json  bool
+	state string
+	since string
 }
 
 func (updated *updatedCommand) Summary() string {
-	return "Read updated date of data from japanpost website. Exit status 0 if later than [argument](yyyyMMdd) or exit status 1."
+	return "Read updated date of data from japanpost website. Exit status 0 if later than [argument](yyyyMMdd), 3 if not later or 1 on error."
 }
 
 func (updated *updatedCommand) SetFlag(fs *flag.FlagSet) {
 	fs.BoolVar(&updated.print, "p", false, "Print updated date.")
+	fs.BoolVar(&updated.json, "json", false, "Print updated date and archives on website in JSON.")
+	fs.StringVar(&updated.state, "state", "", "Compare with the date in the state file of sync at <string> path instead of [argument].")
+	fs.StringVar(&updated.since, "since", "", "Compare with the modification time of the file at <string> path instead of [argument].")
 }
 
-func (updated *updatedCommand) Execute(fs *flag.FlagSet) gosubcommand.ExitCode {
+// compareDate returns the date to compare from the argument, -state or -since.
+func (updated *updatedCommand) compareDate(fs *flag.FlagSet) (time.Time, error) {
+	switch {
+	case updated.state != "":
+		state, err := gokenall.LoadSyncState(updated.state)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return state.Date, nil
+	case updated.since != "":
+		info, err := os.Stat(updated.since)
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "failed to stat file: %s", updated.since)
+		}
+		// the date on website is the day in JST, so compare with the day of the file in JST
+		mod := info.ModTime().In(time.FixedZone("JST", 9*60*60))
+		return time.Date(mod.Year(), mod.Month(), mod.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
 	t, err := time.Parse("20060102", fs.Arg(0))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "date string is wrong format. To compare, `yyyyMMdd` string is required: %s\n", fs.Arg(0))
+		return time.Time{}, errors.Errorf("date string is wrong format. To compare, `yyyyMMdd` string is required: %s", fs.Arg(0))
+	}
+	return t, nil
+}
+
+// releaseJSON is the output of `updated -json`.
+type releaseJSON struct {
+	Updated  bool          `json:"updated"`
+	Date     string        `json:"date"`
+	Archives []archiveJSON `json:"archives"`
+}
+
+type archiveJSON struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	Size int64  `json:"size,omitempty"`
+}
+
+func (updated *updatedCommand) Execute(fs *flag.FlagSet) gosubcommand.ExitCode {
+	if updated.state != "" && updated.since != "" || (updated.state != "" || updated.since != "") && fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "only one of [argument], -state and -since can be used")
+		return gosubcommand.ExitCodeUsage
+	}
+	t, err := updated.compareDate(fs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		return gosubcommand.ExitCodeError
 	}
 
-	result, updatedTime, err := gokenall.Updated(t)
+	info, err := gokenall.FetchReleaseInfo("")
+	if err == nil && info.Date.IsZero() {
+		err = errors.New("not found updated date string in website")
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return gosubcommand.ExitCodeError
 	}
+	result := t.Before(info.Date)
+
+	if updated.json {
+		out := releaseJSON{Updated: result, Date: info.Date.Format("2006-01-02"), Archives: []archiveJSON{}}
+		for _, a := range info.Archives {
+			out.Archives = append(out.Archives, archiveJSON{Kind: a.Kind, Name: a.Name, URL: a.URL, Size: a.Size})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return gosubcommand.ExitCodeError
+		}
+	}
 	if updated.print {
-		fmt.Fprintln(os.Stdout, updatedTime.Format("20060102"))
+		fmt.Fprintln(os.Stdout, info.Date.Format("20060102"))
 	}
 	if !result {
-		return gosubcommand.ExitCodeError
+		return exitCodeNotModified
 	}
 	return gosubcommand.ExitCodeSuccess
 }
 
 type normalizeCommand struct {
-	output string
-	width  bool
-	utf8   bool
-	trim   bool
+	normalizeFlags
+	output  string
+	audit   string
+	source  string
+	archive string
+	entry   string
 }
 
 func (normalize *normalizeCommand) Summary() string {
-	return "Normalize -make easy to use- input (file or standard input if no argument)"
+	return "Normalize -make easy to use- input (csv or zip file, or standard input if no argument)"
 }
 
 func (normalize *normalizeCommand) SetFlag(fs *flag.FlagSet) {
+	normalize.normalizeFlags.set(fs)
 	fs.StringVar(&normalize.output, "o", "", "Save file to <string> path instead of standard output.")
-	fs.BoolVar(&normalize.width, "width", (gokenall.DefaultNormalizeOption&gokenall.NormalizeWidth) != 0, "Convert hankaku kana into zenkaku, ascii letters into hankaku")
-	fs.BoolVar(&normalize.utf8, "utf8", (gokenall.DefaultNormalizeOption&gokenall.NormalizeUTF8) != 0, "Convert ShiftJIS into UTF8")
-	fs.BoolVar(&normalize.trim, "trim", (gokenall.DefaultNormalizeOption&gokenall.NormalizeTrim) != 0, "Trim spaces from each text")
+	fs.StringVar(&normalize.source, "source", "", "Read input from the archive in <string> instead of file or standard input. (same as download -source)")
+	fs.StringVar(&normalize.archive, "archive", "", "Read input from the archive named <string> in -source. (default: ken_all.zip)")
+	fs.StringVar(&normalize.entry, "entry", "", "Read the file named <string> in zip input. (default: all csv files in zip)")
+	fs.StringVar(&normalize.audit, "audit", "", "Save the report of street names not fully normalized to <string> path.")
 }
 
 func (normalize *normalizeCommand) Execute(fs *flag.FlagSet) gosubcommand.ExitCode {
 	input := fs.Arg(0)
 
+	if err := normalize.normalizeFlags.resolve(normalize.output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return gosubcommand.ExitCodeError
+	}
+
 	var r io.Reader
-	var w io.Writer
 
-	if input == "" || input == "-" {
+	if normalize.source != "" {
+		if input != "" {
+			fmt.Fprintln(os.Stderr, "input file cannot be used with -source")
+			return gosubcommand.ExitCodeUsage
+		}
+		src, err := gokenall.ParseSource(normalize.source)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return gosubcommand.ExitCodeUsage
+		}
+		rc := gokenall.OpenCSV(&gokenall.DownloadOptions{Source: src, Archive: normalize.archive, Entry: normalize.entry})
+		defer rc.Close()
+		r = rc
+	} else if input == "" || input == "-" {
 		r = os.Stdin
 	} else {
 		f, err := os.Open(input)
@@ -140,38 +323,336 @@ func (normalize *normalizeCommand) Execute(fs *flag.FlagSet) gosubcommand.ExitCo
 		r = f
 	}
 
+	if normalize.source == "" {
+		normalize.opts.Entry = normalize.entry
+	}
+
+	var audit *gokenall.Audit
+	if normalize.audit != "" {
+		audit = gokenall.NewAudit()
+	}
+	normalize.opts.Audit = audit
+
+	var err error
 	if normalize.output == "" || normalize.output == "-" {
-		w = os.Stdout
+		err = gokenall.NormalizeWithOptions(r, os.Stdout, normalize.opts)
 	} else {
-		f, err := os.Create(normalize.output)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, errors.Wrapf(err, "failed to create file: %s", normalize.output))
+		err = writeFileAtomic(normalize.output, func(w io.Writer) error {
+			return gokenall.NormalizeWithOptions(r, w, normalize.opts)
+		})
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return gosubcommand.ExitCodeError
+	}
+
+	if audit != nil {
+		if err := writeFileAtomic(normalize.audit, audit.WriteCSV); err != nil {
+			fmt.Fprintln(os.Stderr, err)
 			return gosubcommand.ExitCodeError
 		}
-		defer f.Close()
-		w = f
 	}
 
-	option := gokenall.DefaultNormalizeOption
-	if normalize.width {
-		option |= gokenall.NormalizeWidth
-	} else {
-		option &^= gokenall.NormalizeWidth
+	return gosubcommand.ExitCodeSuccess
+}
+
+type syncCommand struct {
+	normalizeFlags
+	state   string
+	output  string
+	source  string
+	archive string
+	retries int
+	minRows int
+	force   bool
+}
+
+func (sync *syncCommand) Summary() string {
+	return "Download and normalize into -o file if updated since the last sync in -state file. Exit status 0 if updated, 3 if not updated or 1 on error."
+}
+
+func (sync *syncCommand) SetFlag(fs *flag.FlagSet) {
+	sync.normalizeFlags.set(fs)
+	fs.StringVar(&sync.state, "state", "", "Save the state of the last sync to <string> path. (required)")
+	fs.StringVar(&sync.output, "o", "", "Save file to <string> path. The file is replaced only when synced successfully. (required)")
+	fs.StringVar(&sync.source, "source", "", "Read archive from <string> instead of japanpost website. (same as download -source)")
+	fs.StringVar(&sync.archive, "archive", "", "Read the archive named <string>. (default: ken_all.zip)")
+	fs.IntVar(&sync.retries, "retries", 0, "Retry <int> times with backoff on network errors and 429/5xx status.")
+	fs.IntVar(&sync.minRows, "min-rows", 0, "Verify the extracted file is in ken_all format and has <int> rows at least.")
+	fs.BoolVar(&sync.force, "f", false, "Download and normalize even if not updated.")
+}
+
+func (sync *syncCommand) Execute(fs *flag.FlagSet) gosubcommand.ExitCode {
+	if sync.state == "" || sync.output == "" {
+		fmt.Fprintln(os.Stderr, "-state and -o are required")
+		return gosubcommand.ExitCodeUsage
 	}
-	if normalize.utf8 {
-		option |= gokenall.NormalizeUTF8
-	} else {
-		option &^= gokenall.NormalizeUTF8
+	if err := sync.normalizeFlags.resolve(sync.output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return gosubcommand.ExitCodeError
 	}
-	if normalize.trim {
-		option |= gokenall.NormalizeTrim
+
+	download := &gokenall.DownloadOptions{
+		Archive: sync.archive,
+		MinRows: sync.minRows,
+	}
+	if sync.source != "" {
+		src, err := gokenall.ParseSource(sync.source)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return gosubcommand.ExitCodeUsage
+		}
+		download.Source = src
+	}
+	if sync.retries > 0 {
+		retry := gokenall.DefaultRetryPolicy
+		retry.Attempts = sync.retries + 1
+		download.Retry = &retry
+	}
+
+	_, err := gokenall.Sync(sync.output, &gokenall.SyncOptions{
+		State:     sync.state,
+		Download:  download,
+		Normalize: sync.opts,
+		Force:     sync.force,
+	})
+	if err == gokenall.ErrNotModified {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeNotModified
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return gosubcommand.ExitCodeError
+	}
+	return gosubcommand.ExitCodeSuccess
+}
+
+// normalizeFlags is the flags of NormalizeOptions shared by normalize and sync.
+type normalizeFlags struct {
+	rules      string
+	encoding   string
+	lineEnding string
+	kana       string
+	longVowel  string
+	letterCase string
+	compress   string
+	opts       *gokenall.NormalizeOptions
+}
+
+func (nf *normalizeFlags) set(fs *flag.FlagSet) {
+	defaults := gokenall.DefaultNormalizeOptions()
+	nf.opts = &gokenall.NormalizeOptions{}
+	fs.BoolVar(&nf.opts.Width, "width", defaults.Width, "Convert hankaku kana into zenkaku, ascii letters into hankaku")
+	fs.BoolVar(&nf.opts.UTF8, "utf8", defaults.UTF8, "Convert ShiftJIS into UTF8")
+	fs.BoolVar(&nf.opts.Trim, "trim", defaults.Trim, "Trim spaces from each text")
+	fs.BoolVar(&nf.opts.StreetNote, "note", defaults.StreetNote, "Append columns of the note removed from street name")
+	fs.BoolVar(&nf.opts.Building, "building", defaults.Building, "Append columns of building name and floor for high-rise buildings")
+	fs.BoolVar(&nf.opts.Trace, "trace", defaults.Trace, "Append columns of source lines, applied rules and original street name")
+	fs.BoolVar(&nf.opts.Strict, "strict", defaults.Strict, "Fail if input ends in the middle of multi-line row instead of outputting the rows without merging")
+	fs.BoolVar(&nf.opts.KeepRange, "range", defaults.KeepRange, "Keep ranges of street numbers in one row with columns of from, to and unit instead of splitting into rows")
+	fs.IntVar(&nf.opts.RangeLimit, "range-limit", gokenall.DefaultRangeLimit, "Split ranges of street numbers into <int> rows at most")
+	fs.StringVar(&nf.encoding, "encoding", "", fmt.Sprintf("Output encoding. (%s) cp932 is an alias of sjis. eucjp fails on characters only in cp932 like ①. Overrides -utf8 if set.", joinEncodings(gokenall.Encodings())))
+	fs.StringVar(&nf.lineEnding, "newline", "lf", "Output line ending. (lf,crlf)")
+	fs.BoolVar(&nf.opts.FinalLineEnding, "final-newline", defaults.FinalLineEnding, "Output line ending after the last line")
+	fs.StringVar(&nf.kana, "kana", "", fmt.Sprintf("Convert kana columns into the form. (%s) Overrides -width for kana columns if set.", joinKanaForms(gokenall.KanaForms())))
+	fs.BoolVar(&nf.opts.Romaji, "romaji", defaults.Romaji, "Append columns of romaji converted from kana columns")
+	fs.StringVar(&nf.longVowel, "romaji-long", defaults.RomajiStyle.LongVowel.String(), "Notation of long vowels in romaji. (macron,circumflex,omit,double)")
+	fs.StringVar(&nf.letterCase, "romaji-case", defaults.RomajiStyle.Case.String(), "Letter case of romaji. (lower,title,upper)")
+	fs.BoolVar(&nf.opts.SearchKey, "search-key", defaults.SearchKey, "Append column of the search key of street name folding character variants")
+	fs.StringVar(&nf.compress, "compress", "", fmt.Sprintf("Compress output. (%s) (default: by the extension of -o like .gz or .zst)", joinCompressions(gokenall.Compressions())))
+	fs.StringVar(&nf.rules, "rules", "", fmt.Sprintf("Run street rules of comma separated names in the order. Prefix every name with '-' to disable them instead. (%s)", strings.Join(gokenall.StreetRuleNames(), ",")))
+}
+
+// resolve sets the options from the string flags. output is the path of -o.
+func (nf *normalizeFlags) resolve(output string) error {
+	rules, err := streetRuleNames(nf.rules)
+	if err != nil {
+		return err
+	}
+	nf.opts.Rules = rules
+	nf.opts.Encoding = gokenall.Encoding(nf.encoding)
+	nf.opts.Kana = gokenall.KanaForm(nf.kana)
+	if err := nf.opts.RomajiStyle.LongVowel.UnmarshalText([]byte(nf.longVowel)); err != nil {
+		return err
+	}
+	if err := nf.opts.RomajiStyle.Case.UnmarshalText([]byte(nf.letterCase)); err != nil {
+		return err
+	}
+	switch nf.lineEnding {
+	case "lf":
+		nf.opts.LineEnding = gokenall.LineEndingLF
+	case "crlf":
+		nf.opts.LineEnding = gokenall.LineEndingCRLF
+	default:
+		return errors.Errorf("unknown newline: %s", nf.lineEnding)
+	}
+	nf.opts.Compression = gokenall.Compression(nf.compress)
+	if nf.compress == "" && output != "" && output != "-" {
+		if nf.opts.Compression, err = gokenall.CompressionByExt(output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type explainCommand struct {
+	rules string
+}
+
+func (explain *explainCommand) Summary() string {
+	return "Explain how rows of [argument](zip code) are normalized from input (file or standard input if no second argument)"
+}
+
+func (explain *explainCommand) SetFlag(fs *flag.FlagSet) {
+	fs.StringVar(&explain.rules, "rules", "", "Run street rules of comma separated names in the order. Same as normalize.")
+}
+
+func (explain *explainCommand) Execute(fs *flag.FlagSet) gosubcommand.ExitCode {
+	zipCode := fs.Arg(0)
+	if zipCode == "" {
+		fmt.Fprintln(os.Stderr, "zip code is required")
+		return gosubcommand.ExitCodeError
+	}
+	input := fs.Arg(1)
+
+	var r io.Reader
+	if input == "" || input == "-" {
+		r = os.Stdin
 	} else {
-		option &^= gokenall.NormalizeTrim
+		f, err := os.Open(input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, errors.Wrapf(err, "failed to open file: %s", input))
+			return gosubcommand.ExitCodeError
+		}
+		defer f.Close()
+		r = f
+	}
+
+	opts := gokenall.DefaultNormalizeOptions()
+	rules, err := streetRuleNames(explain.rules)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return gosubcommand.ExitCodeError
 	}
-	if err := gokenall.Normalize(r, w, option); err != nil {
+	opts.Rules = rules
+
+	list, err := gokenall.ExplainWithOptions(r, zipCode, opts)
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return gosubcommand.ExitCodeError
 	}
+	if len(list) == 0 {
+		fmt.Fprintf(os.Stderr, "zip code is not found: %s\n", zipCode)
+		return gosubcommand.ExitCodeError
+	}
+
+	for i, p := range list {
+		if i > 0 {
+			fmt.Fprintln(os.Stdout)
+		}
+		lines := make([]string, len(p.Trace.SourceLines))
+		for j, line := range p.Trace.SourceLines {
+			lines[j] = fmt.Sprint(line)
+		}
+		fmt.Fprintf(os.Stdout, "%s %s%s\n", p.ZipCode, p.Pref, p.City)
+		fmt.Fprintf(os.Stdout, "  1. source lines: %s\n", strings.Join(lines, ", "))
+		fmt.Fprintf(os.Stdout, "  2. street:       %s\n", p.Trace.OriginalStreet)
+		for j, rule := range p.Trace.Rules {
+			fmt.Fprintf(os.Stdout, "  %d. rule:         %s\n", j+3, rule)
+		}
+		fmt.Fprintf(os.Stdout, "  => street:      %s (%s)\n", p.Street, p.StreetKana)
+		if p.StreetNote != "" {
+			fmt.Fprintf(os.Stdout, "  => note:        %s (%s)\n", p.StreetNote, p.StreetNoteType)
+		}
+		if p.Building != "" {
+			fmt.Fprintf(os.Stdout, "  => building:    %s %s\n", p.Building, p.Floor)
+		}
+	}
 
 	return gosubcommand.ExitCodeSuccess
 }
+
+// streetRuleNames parses the comma separated names of the street rules to run.
+// If every name is prefixed with '-', the rules are disabled keeping the default order of the others.
+func streetRuleNames(rules string) ([]string, error) {
+	if rules == "" {
+		return nil, nil
+	}
+	names := strings.Split(rules, ",")
+	disabled := map[string]bool{}
+	count := 0
+	for _, name := range names {
+		if strings.HasPrefix(name, "-") {
+			disabled[strings.TrimPrefix(name, "-")] = true
+			count++
+		}
+	}
+	if count == 0 {
+		return names, nil
+	}
+	if count != len(names) {
+		return nil, errors.Errorf("rules must be either all enabled or all disabled: %s", rules)
+	}
+	enabled := []string{}
+	for _, name := range gokenall.StreetRuleNames() {
+		if disabled[name] {
+			delete(disabled, name)
+			continue
+		}
+		enabled = append(enabled, name)
+	}
+	for name := range disabled {
+		return nil, errors.Errorf("unknown street rule: %s", name)
+	}
+	return enabled, nil
+}
+
+func joinEncodings(encodings []gokenall.Encoding) string {
+	names := make([]string, len(encodings))
+	for i, encoding := range encodings {
+		names[i] = string(encoding)
+	}
+	return strings.Join(names, ",")
+}
+
+func joinKanaForms(forms []gokenall.KanaForm) string {
+	names := make([]string, len(forms))
+	for i, form := range forms {
+		names[i] = string(form)
+	}
+	return strings.Join(names, ",")
+}
+
+func joinCompressions(compressions []gokenall.Compression) string {
+	names := make([]string, len(compressions))
+	for i, compression := range compressions {
+		names[i] = string(compression)
+	}
+	return strings.Join(names, ",")
+}
+
+// writeFileAtomic writes the file name by write through a temporary file in the same directory,
+// so the file is not left truncated when write fails.
+func writeFileAtomic(name string, write func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(name), "."+filepath.Base(name))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create file: %s", name)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := write(f); err != nil {
+		return err
+	}
+	// TempFile creates the file readable only by the owner
+	if err := f.Chmod(0644); err != nil {
+		return errors.Wrapf(err, "failed to change mode of file: %s", name)
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write file: %s", name)
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		return errors.Wrapf(err, "failed to rename file: %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package gokenall
+
+import (
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Compression is the compression of the output at normalize.
+type Compression string
+
+const (
+	// CompressionNone is not compressed. Same as the empty string.
+	CompressionNone Compression = "none"
+	// CompressionGzip is gzip.
+	CompressionGzip Compression = "gzip"
+	// CompressionZstd is zstd. It is supported only when built with Go 1.22 or later.
+	CompressionZstd Compression = "zstd"
+)
+
+// zstd is implemented in compress_zstd.go, which needs Go 1.22 or later.
+// The functions are nil if not supported.
+var (
+	newZstdWriter func(w io.Writer) (io.WriteCloser, error)
+	newZstdReader func(r io.Reader) (io.ReadCloser, error)
+)
+
+// Compressions returns all supported compressions in the order of the definition.
+func Compressions() []Compression {
+	compressions := []Compression{CompressionNone, CompressionGzip}
+	if newZstdWriter != nil {
+		compressions = append(compressions, CompressionZstd)
+	}
+	return compressions
+}
+
+// CompressionByExt returns the compression for the extension of the file name like `.gz`.
+// Returns CompressionNone for the extensions not compressed.
+func CompressionByExt(name string) (Compression, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gz":
+		return CompressionGzip, nil
+	case ".zst", ".zstd":
+		if newZstdWriter == nil {
+			return "", errors.Errorf("zstd is not supported (built with Go 1.22 or later is required): %s", name)
+		}
+		return CompressionZstd, nil
+	}
+	return CompressionNone, nil
+}
+
+// writer returns the writer compressing to w. nil for CompressionNone.
+func (compression Compression) writer(w io.Writer) (io.WriteCloser, error) {
+	switch compression {
+	case "", CompressionNone:
+		return nil, nil
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		if newZstdWriter == nil {
+			return nil, errors.New("zstd is not supported (built with Go 1.22 or later is required)")
+		}
+		return newZstdWriter(w)
+	}
+	return nil, errors.Errorf("unknown compression: %s", compression)
+}
@@ -0,0 +1,66 @@
+package gokenall
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestCompressionByExt(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Compression
+		wantErr bool
+	}{
+		{"ken_all.csv", CompressionNone, false},
+		{"ken_all.csv.gz", CompressionGzip, false},
+		{"KEN_ALL.CSV.GZ", CompressionGzip, false},
+	}
+	for _, tt := range tests {
+		got, err := CompressionByExt(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CompressionByExt(%s) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CompressionByExt(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeWithOptions_gzip(t *testing.T) {
+	input, _ := japanese.ShiftJIS.NewEncoder().String("01101,\"060  \",\"0600000\",\"ﾎｯｶｲﾄﾞｳ\",\"ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ\",\"\",\"北海道\",\"札幌市中央区\",\"\",0,0,0,0,0,0\r\n")
+	want := "01101,\"060\",\"0600000\",\"ホッカイドウ\",\"サッポロシチュウオウク\",\"\",\"北海道\",\"札幌市中央区\",\"\",0,0,0,0,0,0"
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(input))
+	gz.Close()
+
+	opts := DefaultNormalizeOptions()
+	opts.Compression = CompressionGzip
+	var output bytes.Buffer
+	if err := NormalizeWithOptions(&compressed, &output, opts); err != nil {
+		t.Fatalf("NormalizeWithOptions() error = %+v", err)
+	}
+
+	r, err := gzip.NewReader(&output)
+	if err != nil {
+		t.Fatalf("output is not gzip: %v", err)
+	}
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("NormalizeWithOptions() = %q, want %q", got, want)
+	}
+
+	opts.Compression = "lz4"
+	if err := NormalizeWithOptions(bytes.NewReader([]byte(input)), ioutil.Discard, opts); err == nil {
+		t.Errorf("NormalizeWithOptions() with unknown compression error = nil")
+	}
+}
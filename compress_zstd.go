@@ -0,0 +1,28 @@
+//go:build go1.22
+// +build go1.22
+
+package gokenall
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	newZstdWriter = func(w io.Writer) (io.WriteCloser, error) {
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create zstd writer")
+		}
+		return zw, nil
+	}
+	newZstdReader = func(r io.Reader) (io.ReadCloser, error) {
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read zstd input")
+		}
+		return zr.IOReadCloser(), nil
+	}
+}
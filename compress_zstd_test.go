@@ -0,0 +1,63 @@
+//go:build go1.22
+// +build go1.22
+
+package gokenall
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestCompressionByExt_zstd(t *testing.T) {
+	for _, name := range []string{"ken_all.csv.zst", "KEN_ALL.CSV.ZSTD"} {
+		got, err := CompressionByExt(name)
+		if err != nil || got != CompressionZstd {
+			t.Errorf("CompressionByExt(%s) = %v, %v, want %v", name, got, err, CompressionZstd)
+		}
+	}
+}
+
+func TestNormalizeWithOptions_zstd(t *testing.T) {
+	input, _ := japanese.ShiftJIS.NewEncoder().String("01101,\"060  \",\"0600000\",\"ﾎｯｶｲﾄﾞｳ\",\"ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ\",\"\",\"北海道\",\"札幌市中央区\",\"\",0,0,0,0,0,0\r\n")
+	want := "01101,\"060\",\"0600000\",\"ホッカイドウ\",\"サッポロシチュウオウク\",\"\",\"北海道\",\"札幌市中央区\",\"\",0,0,0,0,0,0"
+
+	var compressed bytes.Buffer
+	zw, err := zstd.NewWriter(&compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write(storedZip(t, "KEN_ALL.CSV", input))
+	zw.Close()
+
+	opts := DefaultNormalizeOptions()
+	opts.Compression = CompressionZstd
+	var output bytes.Buffer
+	if err := NormalizeWithOptions(bytes.NewReader(compressed.Bytes()), &output, opts); err != nil {
+		t.Fatalf("NormalizeWithOptions() error = %+v", err)
+	}
+
+	zr, err := zstd.NewReader(&output)
+	if err != nil {
+		t.Fatalf("output is not zstd: %v", err)
+	}
+	defer zr.Close()
+	got, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("NormalizeWithOptions() = %q, want %q", got, want)
+	}
+
+	list, err := Parse(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatalf("Parse() error = %+v", err)
+	}
+	if len(list) != 1 || list[0].Pref != "北海道" {
+		t.Errorf("Parse() = %v", list)
+	}
+}
@@ -0,0 +1,564 @@
+package gokenall
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var zipCodePattern = regexp.MustCompile(`^\d{7}$`)
+
+// ErrNotModified is the error when the file on japanpost website is not modified since the cached one.
+var ErrNotModified = errors.New("ken_all file is not modified since the last download")
+
+// DownloadOptions is the options at download.
+type DownloadOptions struct {
+	Extract  bool         // zip を解凍して csv を出力する
+	CacheDir string       // ダウンロードしたファイルと ETag・Last-Modified の保存先（空の場合はキャッシュしない）
+	Source   Source       // アーカイブの取得元（nil の場合は日本郵便のサイト）
+	Archive  string       // アーカイブのファイル名（空の場合は ken_all.zip）
+	URL      string       // ダウンロードする URL（空の場合は Source と Archive から決める）
+	Entry    string       // 解凍する zip 内のファイル名（空の場合は URL から推測し、見つからなければ唯一のファイル）
+	MinRows  int          // 解凍するファイルが ken_all 形式で最低限含むべき行数（0 の場合は確認しない）
+	Retry    *RetryPolicy // 失敗した場合のリトライ（nil の場合はリトライしない）
+	Progress Progress     // ダウンロードの進捗を受け取る関数（nil の場合は通知しない）
+	Client   *http.Client // HTTP クライアント（nil の場合は http.DefaultClient）
+}
+
+// Progress is called while downloading with the bytes received and the total bytes.
+// The total is -1 if unknown. The received bytes are reset to 0 at retry.
+type Progress func(received, total int64)
+
+// RetryPolicy is the policy to retry the download.
+// The download is retried on network errors and the status codes in StatusCodes,
+// waiting for Wait doubled at every retry with jitter.
+type RetryPolicy struct {
+	Attempts    int           // 最大の試行回数（1 以下の場合はリトライしない）
+	Wait        time.Duration // 最初のリトライまでの待ち時間
+	MaxWait     time.Duration // 待ち時間の上限（0 の場合は上限なし）
+	StatusCodes []int         // リトライする HTTP ステータス（nil の場合は 429 と 5xx）
+}
+
+// DefaultRetryPolicy is the RetryPolicy to try 3 times with waiting 1s, 2s.
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, Wait: time.Second, MaxWait: 30 * time.Second}
+
+// DownloadWithOptions is same as Download but takes DownloadOptions.
+// If opts.CacheDir is set, the file is downloaded only when modified since the cached one
+// by sending If-None-Match and If-Modified-Since, and ErrNotModified is returned if not modified.
+// The downloaded file is verified by the CRC in the zip archive (and opts.MinRows)
+// before anything is written to w, so nothing is written when it is broken.
+// If opts.Source is set, the archive is read from it. The cache and the retry are used only for HTTPSource.
+// If opts is nil, the file is downloaded without cache.
+func DownloadWithOptions(w io.Writer, opts *DownloadOptions) error {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	archive := opts.archiveName()
+	src := opts.Source
+	if src == nil {
+		src = &HTTPSource{}
+	}
+
+	// the source name to show in errors and to know the entry name
+	name := archive
+	var cache *downloadCache
+	var req *http.Request
+	client := opts.Client
+	httpSrc, isHTTP := src.(*HTTPSource)
+	if isHTTP {
+		name = opts.URL
+		if name == "" {
+			name = httpSrc.URL(archive)
+		}
+		if httpSrc.Client != nil {
+			client = httpSrc.Client
+		}
+		if client == nil {
+			client = http.DefaultClient
+		}
+
+		var err error
+		if req, err = http.NewRequest(http.MethodGet, name, nil); err != nil {
+			return errors.Wrapf(err, "failed to create request: %s", name)
+		}
+		if opts.CacheDir != "" {
+			if cache, err = loadDownloadCache(opts.CacheDir, archive, name); err != nil {
+				return err
+			}
+			cache.setHeader(req)
+		}
+	}
+
+	entry := opts.Entry
+	if entry == "" {
+		entry = defaultEntryName(name)
+	}
+	exact := opts.Entry != ""
+
+	// zip needs random access, so spool the body to a temporary file
+	// instead of reading into memory or trusting Content-Length.
+	// The file is verified before written to w or saved to the cache.
+	dir := ""
+	if cache != nil {
+		dir = cache.dir
+	}
+	spool, err := ioutil.TempFile(dir, "."+path.Base(archive))
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	var header http.Header
+	var size int64
+	if isHTTP {
+		for attempt := 1; ; attempt++ {
+			header, size, err = fetch(client, req, spool, opts.Progress)
+			if err == nil {
+				break
+			}
+			if err, ok := err.(*statusError); ok && err.code == http.StatusNotModified && cache != nil {
+				return ErrNotModified
+			}
+			wait, retry := opts.Retry.next(attempt, err)
+			if !retry {
+				return err
+			}
+			time.Sleep(wait)
+		}
+	} else {
+		r, err := src.Open(archive)
+		if err != nil {
+			return err
+		}
+		size, err = io.Copy(spool, r)
+		r.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to read archive: %s", archive)
+		}
+	}
+
+	if err := verifyArchive(spool, size, entry, exact, opts.Extract, opts.MinRows); err != nil {
+		return errors.Wrapf(err, "downloaded file is broken: %s", name)
+	}
+	if err := spool.Close(); err != nil {
+		return errors.Wrap(err, "failed to write temporary file")
+	}
+
+	spooled := spool.Name()
+	if cache != nil {
+		if err := cache.save(spooled, header); err != nil {
+			return err
+		}
+		spooled = cache.archivePath()
+	}
+	return writeArchive(w, spooled, opts.Extract, entry, exact)
+}
+
+// OpenCSV returns the reader of the csv file extracted from the archive by DownloadWithOptions with opts.
+// The error of the download is returned by Read. It is used to normalize the archive in a Source like:
+//
+//	r := gokenall.OpenCSV(&gokenall.DownloadOptions{Source: gokenall.DirSource("/mnt/mirror")})
+//	defer r.Close()
+//	err := gokenall.NormalizeWithOptions(r, w, nil)
+func OpenCSV(opts *DownloadOptions) io.ReadCloser {
+	extract := DownloadOptions{}
+	if opts != nil {
+		extract = *opts
+	}
+	extract.Extract = true
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(DownloadWithOptions(pw, &extract))
+	}()
+	return pr
+}
+
+// DownloadFile downloads the file same as DownloadWithOptions and saves it to the file name.
+// The file is written to a temporary file in the same directory and renamed on success,
+// so the last file is kept when the download fails or is not modified.
+// If manifest is true, the SHA-256 of the file is written to `name.sha256` in the format of sha256sum.
+func DownloadFile(name string, opts *DownloadOptions, manifest bool) error {
+	hash := sha256.New()
+	err := writeFileAtomic(name, func(w io.Writer) error {
+		return DownloadWithOptions(io.MultiWriter(w, hash), opts)
+	})
+	if err != nil {
+		return err
+	}
+
+	if manifest {
+		line := fmt.Sprintf("%x  %s\n", hash.Sum(nil), filepath.Base(name))
+		if err := ioutil.WriteFile(name+".sha256", []byte(line), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write manifest: %s.sha256", name)
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes the file name by write through a temporary file in the same directory.
+// The temporary file is renamed to name only when write succeeds.
+func writeFileAtomic(name string, write func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(name), "."+filepath.Base(name))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create file: %s", name)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := write(f); err != nil {
+		return err
+	}
+	// TempFile creates the file readable only by the owner
+	if err := f.Chmod(0644); err != nil {
+		return errors.Wrapf(err, "failed to change mode of file: %s", name)
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write file: %s", name)
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		return errors.Wrapf(err, "failed to rename file: %s", name)
+	}
+	return nil
+}
+
+// statusError is the error of the unexpected HTTP status.
+type statusError struct {
+	url  string
+	code int
+	text string
+}
+
+func (err *statusError) Error() string {
+	return fmt.Sprintf("failed to download url: %s: %s", err.url, err.text)
+}
+
+// temporaryError is the error which may succeed at retry.
+type temporaryError struct {
+	error
+}
+
+// fetch downloads the file by req and writes it to spool from the beginning.
+func fetch(client *http.Client, req *http.Request, spool *os.File, progress Progress) (http.Header, int64, error) {
+	url := req.URL.String()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, temporaryError{errors.Wrapf(err, "failed to download url: %s", url)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, &statusError{url: url, code: resp.StatusCode, text: resp.Status}
+	}
+
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to seek temporary file")
+	}
+	if err := spool.Truncate(0); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to truncate temporary file")
+	}
+	var body io.Reader = resp.Body
+	if progress != nil {
+		body = &progressReader{r: resp.Body, total: resp.ContentLength, progress: progress}
+	}
+	size, err := io.Copy(spool, body)
+	if err != nil {
+		return nil, 0, temporaryError{errors.Wrap(err, "failed to read http body")}
+	}
+	return resp.Header, size, nil
+}
+
+// next returns the wait before the next attempt and whether to retry after the attempt failed with err.
+func (policy *RetryPolicy) next(attempt int, err error) (time.Duration, bool) {
+	if policy == nil || attempt >= policy.Attempts {
+		return 0, false
+	}
+	switch err := err.(type) {
+	case temporaryError:
+	case *statusError:
+		if !policy.retryable(err.code) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	wait := policy.Wait
+	for i := 1; i < attempt && (policy.MaxWait <= 0 || wait < policy.MaxWait); i++ {
+		wait *= 2
+	}
+	if policy.MaxWait > 0 && wait > policy.MaxWait {
+		wait = policy.MaxWait
+	}
+	// jitter between wait/2 and wait not to retry at the same time with others
+	if half := int64(wait / 2); half > 0 {
+		wait = time.Duration(half + rand.Int63n(half+1))
+	}
+	return wait, true
+}
+
+func (policy *RetryPolicy) retryable(code int) bool {
+	if policy.StatusCodes == nil {
+		return code == http.StatusTooManyRequests || code >= 500
+	}
+	for _, c := range policy.StatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+type progressReader struct {
+	r        io.Reader
+	received int64
+	total    int64
+	progress Progress
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.received += int64(n)
+		pr.progress(pr.received, pr.total)
+	}
+	return n, err
+}
+
+// defaultEntryName returns the name of the file in the archive at url like `KEN_ALL.CSV` for `ken_all.zip`.
+func defaultEntryName(url string) string {
+	name := path.Base(url)
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.TrimSuffix(strings.ToLower(name), ".zip") + ".csv"
+}
+
+// selectEntry returns the file named name in the zip archive. The name is compared case-insensitively.
+// Unless exact is true, the only one file is returned when no file is named name.
+func selectEntry(zipReader *zip.Reader, name string, exact bool) (*zip.File, error) {
+	for _, f := range zipReader.File {
+		if strings.EqualFold(path.Base(f.Name), name) {
+			return f, nil
+		}
+	}
+	if !exact && len(zipReader.File) == 1 {
+		return zipReader.File[0], nil
+	}
+	names := make([]string, len(zipReader.File))
+	for i, f := range zipReader.File {
+		names[i] = f.Name
+	}
+	return nil, errors.Errorf("zip file does not contain %s: [%s]", name, strings.Join(names, ", "))
+}
+
+// verifyArchive reads all the files in the zip archive to verify the CRC.
+// If extract is true or minRows is positive, the file named entry must exist,
+// and if minRows is positive, the file must be in ken_all format and have minRows rows at least.
+func verifyArchive(r io.ReaderAt, size int64, entry string, exact bool, extract bool, minRows int) error {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return errors.Wrap(err, "failed to allocate reader")
+	}
+
+	var selected *zip.File
+	if extract || minRows > 0 {
+		if selected, err = selectEntry(zipReader, entry, exact); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range zipReader.File {
+		rc, err := f.Open()
+		if err != nil {
+			return errors.Wrapf(err, "failed to open the decompress file in zip file: %s", f.Name)
+		}
+		if f == selected && minRows > 0 {
+			err = verifyRows(rc, minRows)
+		} else {
+			_, err = io.Copy(ioutil.Discard, rc)
+		}
+		rc.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to verify the file in zip file: %s", f.Name)
+		}
+	}
+	return nil
+}
+
+// verifyRows checks that r is in ken_all format and has minRows rows at least.
+func verifyRows(r io.Reader, minRows int) error {
+	// The bytes of `,` and `"` are never used in the multibyte characters of Shift_JIS,
+	// so the csv can be read without decoding.
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = columnCount
+	rows := 0
+	for {
+		cols, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "not ken_all format at row %d", rows+1)
+		}
+		if rows == 0 && !zipCodePattern.MatchString(cols[2]) {
+			return errors.Errorf("not ken_all format: zip code is %q", cols[2])
+		}
+		rows++
+	}
+	if rows < minRows {
+		return errors.Errorf("too few rows: %d < %d", rows, minRows)
+	}
+	return nil
+}
+
+// writeArchive writes the zip archive name to w. If extract is true, writes the file named entry in it.
+func writeArchive(w io.Writer, name string, extract bool, entry string, exact bool) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open file: %s", name)
+	}
+	defer f.Close()
+
+	if !extract {
+		if _, err = io.Copy(w, f); err != nil {
+			return errors.Wrap(err, "failed to copy from reader to writer")
+		}
+		return nil
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat file: %s", name)
+	}
+	zipReader, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return errors.Wrap(err, "failed to allocate reader")
+	}
+	file, err := selectEntry(zipReader, entry, exact)
+	if err != nil {
+		return err
+	}
+
+	srcFile, err := file.Open()
+	if err != nil {
+		return errors.Wrapf(err, "failed to open the decompress file in zip file: %s", file.Name)
+	}
+	defer srcFile.Close()
+
+	if _, err = io.Copy(w, srcFile); err != nil {
+		return errors.Wrap(err, "failed to copy from decompress file in zip file to writer")
+	}
+
+	return nil
+}
+
+// downloadCache is the downloaded file and its validators saved in the cache directory.
+// downloadCache is the archive downloaded to dir and its validators.
+// The files are named after the archive, so the caches of the different archives don't overwrite each other.
+type downloadCache struct {
+	dir          string
+	archive      string
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func loadDownloadCache(dir, archive, url string) (*downloadCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create cache directory: %s", dir)
+	}
+	cache := &downloadCache{dir: dir, archive: archive, URL: url}
+
+	b, err := ioutil.ReadFile(cache.metaPath())
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read cache: %s", dir)
+	}
+	var saved downloadCache
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse cache: %s", dir)
+	}
+	if _, err := os.Stat(cache.archivePath()); err != nil || saved.URL != url {
+		return cache, nil
+	}
+	cache.ETag = saved.ETag
+	cache.LastModified = saved.LastModified
+	return cache, nil
+}
+
+func (cache *downloadCache) setHeader(req *http.Request) {
+	if cache.ETag != "" {
+		req.Header.Set("If-None-Match", cache.ETag)
+	}
+	if cache.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cache.LastModified)
+	}
+}
+
+// archivePath returns the path of the cached archive.
+func (cache *downloadCache) archivePath() string {
+	return cacheArchivePath(cache.dir, cache.archive)
+}
+
+// metaPath returns the path of the validators of the cached archive.
+func (cache *downloadCache) metaPath() string {
+	return cache.archivePath() + ".json"
+}
+
+// save moves the downloaded file name into the cache directory and saves the validators in header.
+// The stale validators are removed before the archive is replaced and the new ones are written last,
+// so the validators never belong to the other archive on failure.
+func (cache *downloadCache) save(name string, header http.Header) error {
+	cache.ETag = header.Get("ETag")
+	cache.LastModified = header.Get("Last-Modified")
+	b, err := json.Marshal(cache)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal cache")
+	}
+	if err := os.Remove(cache.metaPath()); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove stale cache")
+	}
+	if err := os.Rename(name, cache.archivePath()); err != nil {
+		return errors.Wrap(err, "failed to save downloaded file to cache")
+	}
+	meta := filepath.Join(cache.dir, "."+filepath.Base(cache.metaPath()))
+	if err := ioutil.WriteFile(meta, b, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write cache: %s", meta)
+	}
+	defer os.Remove(meta)
+	if err := os.Rename(meta, cache.metaPath()); err != nil {
+		return errors.Wrap(err, "failed to save cache")
+	}
+	return nil
+}
+
+// cacheArchivePath returns the path of the archive cached in dir.
+func cacheArchivePath(dir, archive string) string {
+	return filepath.Join(dir, path.Base(archive))
+}
+
+// archiveName returns the archive name in opts, or the default one.
+func (opts *DownloadOptions) archiveName() string {
+	if opts.Archive == "" {
+		return path.Base(kenAllFileURL)
+	}
+	return opts.Archive
+}
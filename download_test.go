@@ -0,0 +1,324 @@
+package gokenall
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestDownloadWithOptions_cache(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	f, err := zw.Create("KEN_ALL.CSV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("01101,\"060  \",\"0600000\"\r\n"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	const etag = `"v1"`
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Mon, 30 Sep 2019 00:00:00 GMT")
+		w.Write(archive.Bytes())
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "gokenall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := &DownloadOptions{Extract: true, CacheDir: dir, URL: ts.URL}
+
+	var first bytes.Buffer
+	if err := DownloadWithOptions(&first, opts); err != nil {
+		t.Fatalf("first download: %+v", err)
+	}
+	if got, want := first.String(), "01101,\"060  \",\"0600000\"\r\n"; got != want {
+		t.Errorf("first download = %q, want %q", got, want)
+	}
+
+	var second bytes.Buffer
+	if err := DownloadWithOptions(&second, opts); err != ErrNotModified {
+		t.Errorf("second download error = %v, want ErrNotModified", err)
+	}
+	if second.Len() != 0 {
+		t.Errorf("second download wrote %d bytes, want 0", second.Len())
+	}
+
+	var other bytes.Buffer
+	if err := DownloadWithOptions(&other, &DownloadOptions{CacheDir: dir, URL: ts.URL + "/other"}); err != nil {
+		t.Fatalf("download from other url: %+v", err)
+	}
+	if !bytes.Equal(other.Bytes(), archive.Bytes()) {
+		t.Errorf("download from other url did not write the archive")
+	}
+	if requests != 3 {
+		t.Errorf("requests = %d, want 3", requests)
+	}
+}
+
+func TestDownloadWithOptions_cacheArchives(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	f, err := zw.Create("KEN_ALL.CSV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("01101,\"060  \",\"0600000\"\r\n"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"` + r.URL.Path + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write(archive.Bytes())
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "gokenall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := &HTTPSource{BaseURL: ts.URL + "/"}
+	for _, archive := range []string{"ken_all.zip", "jigyosyo.zip"} {
+		if err := DownloadWithOptions(ioutil.Discard, &DownloadOptions{CacheDir: dir, Source: source, Archive: archive}); err != nil {
+			t.Fatalf("first download of %s: %+v", archive, err)
+		}
+	}
+	// switching the archive keeps the cache of the other one
+	for _, archive := range []string{"ken_all.zip", "jigyosyo.zip"} {
+		if err := DownloadWithOptions(ioutil.Discard, &DownloadOptions{CacheDir: dir, Source: source, Archive: archive}); err != ErrNotModified {
+			t.Errorf("second download of %s error = %v, want ErrNotModified", archive, err)
+		}
+	}
+}
+
+func TestDownloadWithOptions_extract(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for _, name := range []string{"README.TXT", "KEN_ALL.CSV", "ADD_1909.CSV"} {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(name))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		// write in chunks without Content-Length
+		body := archive.Bytes()
+		for len(body) > 0 {
+			n := 64
+			if n > len(body) {
+				n = len(body)
+			}
+			w.Write(body[:n])
+			w.(http.Flusher).Flush()
+			body = body[n:]
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		opts    *DownloadOptions
+		want    string
+		wantErr bool
+	}{
+		{"default", &DownloadOptions{Extract: true, URL: ts.URL + "/ken_all.zip"}, "KEN_ALL.CSV", false},
+		{"entry", &DownloadOptions{Extract: true, URL: ts.URL + "/ken_all.zip", Entry: "add_1909.csv"}, "ADD_1909.CSV", false},
+		{"no entry", &DownloadOptions{Extract: true, URL: ts.URL + "/ken_all.zip", Entry: "DEL_1909.CSV"}, "", true},
+		{"ambiguous", &DownloadOptions{Extract: true, URL: ts.URL + "/archive.zip"}, "", true},
+		{"status", &DownloadOptions{Extract: true, URL: ts.URL + "/missing.zip"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			err := DownloadWithOptions(&b, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DownloadWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("DownloadWithOptions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func storedZip(t *testing.T, name, content string) []byte {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(content))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archive.Bytes()
+}
+
+func TestDownloadFile(t *testing.T) {
+	const (
+		row  = "01101,\"060  \",\"0600000\",\"ﾎｯｶｲﾄﾞｳ\",\"ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ\",\"ｲｶﾆｹｲｻｲｶﾞﾅｲﾊﾞｱｲ\",\"北海道\",\"札幌市中央区\",\"以下に掲載がない場合\",0,0,0,0,0,0\r\n"
+		good = row + row
+	)
+	broken := storedZip(t, "KEN_ALL.CSV", good)
+	i := bytes.Index(broken, []byte("0600000"))
+	broken[i] = '1'
+
+	bodies := map[string][]byte{
+		"/good.zip":   storedZip(t, "KEN_ALL.CSV", good),
+		"/broken.zip": broken,
+		"/other.zip":  storedZip(t, "KEN_ALL.CSV", "a,b,c\r\n"),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bodies[r.URL.Path])
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "gokenall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "ken_all.csv")
+
+	if err := DownloadFile(name, &DownloadOptions{Extract: true, URL: ts.URL + "/good.zip", MinRows: 2}, true); err != nil {
+		t.Fatalf("DownloadFile() error = %+v", err)
+	}
+	b, _ := ioutil.ReadFile(name)
+	if string(b) != good {
+		t.Errorf("DownloadFile() wrote %q, want %q", b, good)
+	}
+	manifest, _ := ioutil.ReadFile(name + ".sha256")
+	if want := "  ken_all.csv\n"; len(manifest) != 64+len(want) || !strings.HasSuffix(string(manifest), want) {
+		t.Errorf("manifest = %q", manifest)
+	}
+
+	for _, opts := range []*DownloadOptions{
+		{Extract: true, URL: ts.URL + "/broken.zip"},
+		{URL: ts.URL + "/broken.zip"},
+		{Extract: true, URL: ts.URL + "/good.zip", MinRows: 3},
+		{Extract: true, URL: ts.URL + "/other.zip", MinRows: 1},
+	} {
+		if err := DownloadFile(name, opts, false); err == nil {
+			t.Errorf("DownloadFile(%s, MinRows=%d) error = nil, want error", opts.URL, opts.MinRows)
+		}
+		if b, _ := ioutil.ReadFile(name); string(b) != good {
+			t.Errorf("DownloadFile(%s, MinRows=%d) overwrote the last file: %q", opts.URL, opts.MinRows, b)
+		}
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("temporary files are left: %d files", len(files))
+	}
+}
+
+func TestDownloadWithOptions_retry(t *testing.T) {
+	archive := storedZip(t, "KEN_ALL.CSV", "KEN_ALL")
+	failures := map[string]int{"/unavailable.zip": 2, "/notfound.zip": 1}
+	requests := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if requests[r.URL.Path] <= failures[r.URL.Path] {
+			if r.URL.Path == "/notfound.zip" {
+				http.NotFound(w, r)
+			} else {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			}
+			return
+		}
+		w.Write(archive)
+	}))
+	defer ts.Close()
+
+	retry := &RetryPolicy{Attempts: 3, Wait: time.Millisecond}
+	var received, total int64
+	progress := func(r, t int64) { received, total = r, t }
+
+	var b bytes.Buffer
+	if err := DownloadWithOptions(&b, &DownloadOptions{URL: ts.URL + "/unavailable.zip", Retry: retry, Progress: progress}); err != nil {
+		t.Fatalf("DownloadWithOptions() error = %+v", err)
+	}
+	if requests["/unavailable.zip"] != 3 {
+		t.Errorf("requests = %d, want 3", requests["/unavailable.zip"])
+	}
+	if received != int64(len(archive)) || total != int64(len(archive)) {
+		t.Errorf("progress = %d/%d, want %d/%d", received, total, len(archive), len(archive))
+	}
+	if !bytes.Equal(b.Bytes(), archive) {
+		t.Errorf("DownloadWithOptions() did not write the archive")
+	}
+
+	if err := DownloadWithOptions(ioutil.Discard, &DownloadOptions{URL: ts.URL + "/notfound.zip", Retry: retry}); err == nil {
+		t.Errorf("DownloadWithOptions() error = nil, want 404 error")
+	}
+	if requests["/notfound.zip"] != 1 {
+		t.Errorf("requests = %d, want 1 (404 is not retried)", requests["/notfound.zip"])
+	}
+}
+
+func TestRetryPolicy_next(t *testing.T) {
+	policy := &RetryPolicy{Attempts: 5, Wait: time.Second, MaxWait: 3 * time.Second}
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 3 * time.Second},
+		{4, 3 * time.Second},
+	}
+	for _, tt := range tests {
+		wait, ok := policy.next(tt.attempt, temporaryError{errors.New("timeout")})
+		if !ok || wait < tt.max/2 || wait > tt.max {
+			t.Errorf("next(%d) = %v, %v, want between %v and %v", tt.attempt, wait, ok, tt.max/2, tt.max)
+		}
+	}
+	if _, ok := policy.next(5, temporaryError{errors.New("timeout")}); ok {
+		t.Errorf("next(5) retries over Attempts")
+	}
+	if _, ok := policy.next(1, &statusError{code: http.StatusNotFound}); ok {
+		t.Errorf("next() retries 404")
+	}
+	if _, ok := (*RetryPolicy)(nil).next(1, temporaryError{errors.New("timeout")}); ok {
+		t.Errorf("nil policy retries")
+	}
+}
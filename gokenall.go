@@ -1,21 +1,17 @@
 package gokenall
 
 import (
-	"archive/zip"
 	"bufio"
-	"bytes"
 	"encoding/csv"
 	"io"
 	"io/ioutil"
-	"net/http"
-	"regexp"
+	"os"
 	"time"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 	"golang.org/x/text/encoding/japanese"
 	"golang.org/x/text/transform"
-	"golang.org/x/text/unicode/norm"
-	"golang.org/x/text/width"
 )
 
 const (
@@ -27,73 +23,23 @@ const (
 // The file on website is zip archived.
 // If extract flag sets true, the file is decompressed to csv file.
 func Download(w io.Writer, extract bool) error {
-	resp, err := http.Get(kenAllFileURL)
-	if err != nil {
-		return errors.Wrapf(err, "failed to download url: %s", kenAllFileURL)
-	}
-	defer resp.Body.Close()
-
-	if !extract {
-		if _, err = io.Copy(w, resp.Body); err != nil {
-			return errors.Wrap(err, "failed to copy from reader to writer")
-		}
-		return nil
-	}
-
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return errors.Wrap(err, "failed to read http body")
-	}
-
-	zipReader, err := zip.NewReader(bytes.NewReader(bodyBytes), resp.ContentLength)
-	if err != nil {
-		return errors.Wrap(err, "failed to allocate reader")
-	}
-	if len(zipReader.File) != 1 {
-		return errors.Errorf("downloaded zip file does not contain 1 file but %d files", len(zipReader.File))
-	}
-
-	srcFile, err := zipReader.File[0].Open()
-	if err != nil {
-		return errors.Wrapf(err, "failed to open the decompress file in zip file: %s", zipReader.File[0].Name)
-	}
-	defer srcFile.Close()
-
-	if _, err = io.Copy(w, srcFile); err != nil {
-		return errors.Wrap(err, "failed to copy from decompress file in zip file to writer")
-	}
-
-	return nil
+	return DownloadWithOptions(w, &DownloadOptions{Extract: extract})
 }
 
 // Updated checks whether the file on japanpost website is updated or not
 // by comparing with the date text in website.
 func Updated(compareDate time.Time) (result bool, updatedDate time.Time, retErr error) {
-	resp, err := http.Get(kenAllSiteURL)
+	info, err := FetchReleaseInfo("")
 	if err != nil {
-		retErr = errors.Wrapf(err, "failed to download url: %s", kenAllSiteURL)
+		retErr = err
 		return
 	}
-	defer resp.Body.Close()
-
-	bytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		retErr = errors.Wrapf(err, "failed to read contents of url: %s", kenAllSiteURL)
-		return
-	}
-
-	reg := regexp.MustCompile(`<small>(\d+年\d+月\d+日)更新</small>`)
-	matches := reg.FindStringSubmatch(string(bytes))
-	if len(matches) == 0 {
+	if info.Date.IsZero() {
 		retErr = errors.New("not found updated date string in website")
 		return
 	}
-	updatedDate, err = time.Parse("2006年1月2日", matches[1])
-	if err != nil {
-		retErr = errors.Wrapf(err, "failed to parse updated date string: %s", matches[1])
-		return
-	}
 
+	updatedDate = info.Date
 	result = compareDate.Before(updatedDate)
 	return
 }
@@ -108,41 +54,209 @@ const (
 	NormalizeUTF8
 	// NormalizeTrim is set if you want to trim the text.
 	NormalizeTrim
+	// NormalizeStreetNote is set if you want to append the street note columns removed from the street name.
+	NormalizeStreetNote
+	// NormalizeBuilding is set if you want to append the building and floor columns of high-rise buildings.
+	NormalizeBuilding
+	// NormalizeTrace is set if you want to append the trace columns of source lines, applied rules and original street name.
+	NormalizeTrace
+	// NormalizeStrict is set if you want an error of *UnterminatedError when the input ends in the middle of the multi-line row.
+	// If not set, the buffered rows are output without merging.
+	NormalizeStrict
+	// NormalizeRange is set if you want to keep the range like `（１～３丁目）` in one row instead of splitting into rows,
+	// and to append the street number columns of the range.
+	NormalizeRange
+	// NormalizeRomaji is set if you want to append the romaji columns converted from the kana columns.
+	NormalizeRomaji
+	// NormalizeSearchKey is set if you want to append the search key column of the street name made by SearchKey.
+	NormalizeSearchKey
 	// bitsNormalizeOption is a number of normalize options.
 	bitsNormalizeOption = iota
 	// NoNormalizeOption represents no flag is set for normalize.
 	NoNormalizeOption = NormalizeOption(0)
-	// AllNormalizeOption represents all flags are set for normalize.
-	AllNormalizeOption = NormalizeOption(1<<bitsNormalizeOption - 1)
+	// AllNormalizeOption represents the width, utf8 and trim flags are set for normalize.
+	// It is kept as it was for compatibility and does not include the flags added later.
+	AllNormalizeOption = NormalizeWidth | NormalizeUTF8 | NormalizeTrim
 	// DefaultNormalizeOption represents default flags are set for normalize.
-	DefaultNormalizeOption = AllNormalizeOption
+	DefaultNormalizeOption = NormalizeWidth | NormalizeUTF8 | NormalizeTrim
 )
 
 // Normalize make original ken_all texts easy to use.
 // See detail information in https://github.com/oirik/gokenall.
 // Optionaly change width / encoding / trim. (default true for all)
+// r may be ken_all.zip as downloaded or gzip compressed, which is detected by the magic number.
 func Normalize(r io.Reader, w io.Writer, option NormalizeOption) error {
-	var inputLines, outputLines int
+	return NormalizeWithOptions(r, w, option.Options())
+}
 
-	csvReader := csv.NewReader(transform.NewReader(r, japanese.ShiftJIS.NewDecoder()))
-	csvReader.ReuseRecord = true
+// NormalizeWithAudit is same as Normalize but also records the inputs
+// whose parenthetical in the street name is not fully handled to audit.
+// If audit is nil, nothing is recorded.
+func NormalizeWithAudit(r io.Reader, w io.Writer, option NormalizeOption, audit *Audit) error {
+	opts := option.Options()
+	opts.Audit = audit
+	return NormalizeWithOptions(r, w, opts)
+}
+
+// NormalizeWithOptions is same as Normalize but takes NormalizeOptions.
+// If opts is nil, DefaultNormalizeOptions is used.
+func NormalizeWithOptions(r io.Reader, w io.Writer, opts *NormalizeOptions) error {
+	if opts == nil {
+		opts = DefaultNormalizeOptions()
+	}
+	option := opts.option()
+
+	var outputLines int
+
+	encoder, err := opts.encoding().encoder()
+	if err != nil {
+		return err
+	}
+	lineEnding, err := opts.lineEnding()
+	if err != nil {
+		return err
+	}
+
+	if err := opts.Kana.validate(); err != nil {
+		return err
+	}
 
-	var writer *bufio.Writer
-	if option&NormalizeWidth == 0 {
-		if option&NormalizeUTF8 == 0 {
-			writer = bufio.NewWriter(transform.NewWriter(w, japanese.ShiftJIS.NewEncoder()))
-		} else {
-			writer = bufio.NewWriter(w)
+	compressor, err := opts.Compression.writer(w)
+	if err != nil {
+		return err
+	}
+	if compressor != nil {
+		w = compressor
+	}
+
+	var checker eucJPChecker
+	var spool *os.File
+	out := w
+	if opts.encoding() == EncodingEUCJP {
+		// some characters of CP932 cannot be written in EUC-JP,
+		// so spool the output not to write a part of it when such a character is found
+		checker = eucJPChecker{}
+		spool, err = ioutil.TempFile("", "gokenall")
+		if err != nil {
+			return errors.Wrap(err, "failed to create temporary file")
 		}
-	} else {
-		if option&NormalizeUTF8 == 0 {
-			writer = bufio.NewWriter(transform.NewWriter(w, transform.Chain(norm.NFD, width.Fold, norm.NFC, japanese.ShiftJIS.NewEncoder())))
-		} else {
-			writer = bufio.NewWriter(transform.NewWriter(w, transform.Chain(norm.NFD, width.Fold, norm.NFC)))
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+		out = spool
+	}
+
+	buffered := bufio.NewWriter(out)
+	var writer io.Writer = buffered
+	var closer io.Closer
+	if encoder != nil {
+		// encode each row before buffering to find the row which cannot be encoded
+		transformWriter := transform.NewWriter(buffered, encoder)
+		writer = transformWriter
+		closer = transformWriter
+	}
+
+	normer, err := opts.newNormalizer()
+	if err != nil {
+		return err
+	}
+
+	err = normalizeRecords(r, opts, normer, func(output *JapanZipCode, inputLines int) error {
+		output = opts.convert(output)
+		outputCSV := output.revertCSV() + output.revertExtraCSV(option)
+
+		if outputLines > 0 {
+			outputCSV = lineEnding + outputCSV
+		}
+		outputLines++
+
+		if c, ok := checker.unsupported(outputCSV); ok {
+			return errors.Errorf("failed to encode %q to %s: input-line=%d output-line=%d", c, EncodingEUCJP, inputLines, outputLines)
+		}
+		if _, err := io.WriteString(writer, outputCSV); err != nil {
+			return errors.Wrapf(err, "failed to write string to output: input-line=%d output-line=%d", inputLines, outputLines)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if opts.FinalLineEnding && outputLines > 0 {
+		if _, err := io.WriteString(writer, lineEnding); err != nil {
+			return errors.Wrapf(err, "failed to write string to output: output-line=%d", outputLines)
 		}
 	}
+	if closer != nil {
+		// transform.Writer keeps the last characters until closed
+		if err := closer.Close(); err != nil {
+			return errors.Wrap(err, "failed to flush output")
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush output")
+	}
+	if spool != nil {
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			return errors.Wrap(err, "failed to seek temporary file")
+		}
+		if _, err := io.Copy(w, spool); err != nil {
+			return errors.Wrap(err, "failed to write output")
+		}
+	}
+	if compressor != nil {
+		if err := compressor.Close(); err != nil {
+			return errors.Wrap(err, "failed to flush output")
+		}
+	}
+	return nil
+}
 
-	normer := newNormalizer()
+// Explain normalizes ken_all texts like Normalize and returns the rows of the zip code
+// with the trace which tells the source lines and the applied rules.
+// The texts are not converted by width option.
+func Explain(r io.Reader, zipCode string, option NormalizeOption) ([]*JapanZipCode, error) {
+	return ExplainWithOptions(r, zipCode, option.Options())
+}
+
+// ExplainWithOptions is same as Explain but takes NormalizeOptions.
+// The trace is always set regardless of opts.Trace.
+// If opts is nil, DefaultNormalizeOptions is used.
+func ExplainWithOptions(r io.Reader, zipCode string, opts *NormalizeOptions) ([]*JapanZipCode, error) {
+	if opts == nil {
+		opts = DefaultNormalizeOptions()
+	}
+	list := []*JapanZipCode{}
+
+	normer, err := opts.newNormalizer()
+	if err != nil {
+		return nil, err
+	}
+	normer.trace = true
+
+	err = normalizeRecords(r, opts, normer, func(output *JapanZipCode, inputLines int) error {
+		if output.ZipCode == zipCode {
+			list = append(list, output)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// normalizeRecords reads ken_all texts from r and calls fn with each normalized row.
+// r may be a zip archive of ken_all texts.
+func normalizeRecords(r io.Reader, opts *NormalizeOptions, normer *normalizer, fn func(output *JapanZipCode, inputLines int) error) error {
+	var inputLines int
+
+	input, err := openInput(r, opts.Entry)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	csvReader := csv.NewReader(transform.NewReader(input, japanese.ShiftJIS.NewDecoder()))
+	csvReader.ReuseRecord = true
 
 	for {
 
@@ -157,41 +271,62 @@ func Normalize(r io.Reader, w io.Writer, option NormalizeOption) error {
 				}
 				return errors.Wrap(err, "failed to read csv")
 			}
-			input, err = parseArray(cols, (option&NormalizeTrim != 0))
+			input, err = parseArray(cols, opts.Trim)
 			if err != nil {
 				return errors.Wrapf(err, "failed to parse data: input-line=%d", inputLines)
 			}
 		}
 
-		normer.push(input)
+		if err := normer.push(input); err != nil {
+			return errors.Wrapf(err, "failed to normalize data: input-line=%d", inputLines)
+		}
 		for normer.canPop() {
-
-			output := normer.pop()
-			outputCSV := output.revertCSV()
-
-			if outputLines > 0 {
-				outputCSV = "\n" + outputCSV
+			if err := fn(normer.pop(), inputLines); err != nil {
+				return err
 			}
-			outputLines++
+		}
+	}
 
-			_, err := writer.WriteString(outputCSV)
-			if err != nil {
-				return errors.Wrapf(err, "failed to write string to output: input-line=%d output-line=%d", inputLines, outputLines)
-			}
+	if err := normer.flush(opts.Strict); err != nil {
+		return err
+	}
+	for normer.canPop() {
+		if err := fn(normer.pop(), inputLines); err != nil {
+			return err
 		}
 	}
-	writer.Flush()
 	return nil
 }
 
 // Parse parses input csv texts to JapanZipCode data structure.
 func Parse(r io.Reader) ([]*JapanZipCode, error) {
+	return ParseWithOption(r, NoNormalizeOption)
+}
+
+// ParseWithOption parses input csv texts normalized with the option to JapanZipCode data structure.
+// The option tells which additional columns follow the ken_all columns.
+// r may be gzip compressed, or a zip archive of the csv files, which are read in the order in the archive.
+// The texts may be UTF-8 or Shift_JIS like ken_all.zip as downloaded. The line not valid in UTF-8 is decoded from Shift_JIS.
+func ParseWithOption(r io.Reader, option NormalizeOption) ([]*JapanZipCode, error) {
+	input, err := openInput(r, "")
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
 	var inputLines int
 	list := []*JapanZipCode{}
-	scanner := bufio.NewScanner(r)
+	decoder := japanese.ShiftJIS.NewDecoder()
+	scanner := bufio.NewScanner(input)
 	for scanner.Scan() {
 		inputLines++
-		p, err := parseCSV(scanner.Text(), false)
+		line := scanner.Text()
+		if !utf8.ValidString(line) {
+			if line, err = decoder.String(line); err != nil {
+				return nil, errors.Wrapf(err, "failed to decode line: input-line=%d", inputLines)
+			}
+		}
+		p, err := parseCSVWithOption(line, option)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to parse line: input-line=%d", inputLines)
 		}
@@ -0,0 +1,55 @@
+package gokenall
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestNormalize_truncated(t *testing.T) {
+	input, _ := japanese.ShiftJIS.NewEncoder().String(strings.Join([]string{
+		`01101,"064  ","0640941","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","ｱｻﾋｶﾞｵｶ","北海道","札幌市中央区","旭ケ丘",0,0,1,0,0,0`,
+		`01224,"066  ","0660005","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","ｷｮｳﾜ(88-2､271-10､","北海道","千歳市","協和（８８－２、２７１－１０、",1,0,0,0,0,0`,
+	}, "\r\n"))
+
+	tests := []struct {
+		name    string
+		option  NormalizeOption
+		want    string
+		wantErr bool
+	}{
+		{"not strict", NormalizeUTF8, strings.Join([]string{
+			`01101,"064  ","0640941","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","ｱｻﾋｶﾞｵｶ","北海道","札幌市中央区","旭ケ丘",0,0,1,0,0,0`,
+			`01224,"066  ","0660005","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","ｷｮｳﾜ(88-2､271-10､","北海道","千歳市","協和（８８－２、２７１－１０、",1,0,0,0,0,0`,
+		}, "\n"), false},
+		{"strict", NormalizeUTF8 | NormalizeStrict, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w bytes.Buffer
+			err := Normalize(strings.NewReader(input), &w, tt.option)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if _, ok := err.(*UnterminatedError); tt.wantErr && !ok {
+				t.Errorf("Normalize() error = %T, want *UnterminatedError", err)
+			}
+			if !tt.wantErr && w.String() != tt.want {
+				t.Errorf("Normalize() = %v, want %v", w.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize_invalidNumeral(t *testing.T) {
+	input, _ := japanese.ShiftJIS.NewEncoder().String(
+		`13101,"100  ","1000000","ﾄｳｷｮｳﾄ","ﾁﾖﾀﾞｸ","ﾃｽﾄ(1-99999999999ﾁｮｳﾒ)","東京都","千代田区","テスト（１～９９９９９９９９９９９丁目）",0,0,1,0,0,0`)
+
+	var w bytes.Buffer
+	if err := Normalize(strings.NewReader(input), &w, DefaultNormalizeOption); err == nil {
+		t.Errorf("Normalize() error = nil, want error for invalid numeral")
+	}
+}
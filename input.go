@@ -0,0 +1,170 @@
+package gokenall
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// zipMagic is the magic number at the beginning of zip archives.
+	zipMagic = []byte("PK\x03\x04")
+	// gzipMagic is the magic number at the beginning of gzip files.
+	gzipMagic = []byte{0x1f, 0x8b}
+	// zstdMagic is the magic number at the beginning of zstd frames.
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// openInput returns the reader of the csv texts in r.
+// If r is gzip or zstd compressed detected by the magic number, r is decompressed.
+// If r is a zip archive detected by the magic number, the file named entry in it is read,
+// or all the csv files are read in the order in the archive if entry is empty.
+// The archive is spooled to a temporary file, which is removed on Close.
+// Otherwise r is read as it is.
+func openInput(r io.Reader, entry string) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read gzip input")
+		}
+		return openCompressedInput(gz, entry)
+	}
+	if magic, _ := br.Peek(len(zstdMagic)); bytes.Equal(magic, zstdMagic) {
+		if newZstdReader == nil {
+			return nil, errors.New("zstd input is not supported (built with Go 1.22 or later is required)")
+		}
+		zr, err := newZstdReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return openCompressedInput(zr, entry)
+	}
+	if magic, _ := br.Peek(len(zipMagic)); !bytes.Equal(magic, zipMagic) {
+		return ioutil.NopCloser(br), nil
+	}
+
+	spool, err := ioutil.TempFile("", "gokenall")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary file")
+	}
+	input := &zipInput{spool: spool}
+	size, err := io.Copy(spool, br)
+	if err != nil {
+		input.Close()
+		return nil, errors.Wrap(err, "failed to read zip input")
+	}
+	zipReader, err := zip.NewReader(spool, size)
+	if err != nil {
+		input.Close()
+		return nil, errors.Wrap(err, "failed to read zip input")
+	}
+
+	var files []*zip.File
+	if entry != "" {
+		f, err := selectEntry(zipReader, entry, true)
+		if err != nil {
+			input.Close()
+			return nil, err
+		}
+		files = []*zip.File{f}
+	} else {
+		for _, f := range zipReader.File {
+			if strings.EqualFold(path.Ext(f.Name), ".csv") {
+				files = append(files, f)
+			}
+		}
+		if len(files) == 0 {
+			files = zipReader.File
+		}
+	}
+
+	readers := make([]io.Reader, 0, len(files)*2)
+	var last *lastByteReader
+	for _, f := range files {
+		rc, err := f.Open()
+		if err != nil {
+			input.Close()
+			return nil, errors.Wrapf(err, "failed to open the decompress file in zip file: %s", f.Name)
+		}
+		input.files = append(input.files, rc)
+		if last != nil {
+			// the files may not end with a line ending
+			readers = append(readers, &lineSeparator{last: last})
+		}
+		last = &lastByteReader{r: rc}
+		readers = append(readers, last)
+	}
+	input.Reader = io.MultiReader(readers...)
+	return input, nil
+}
+
+func openCompressedInput(decompressor io.ReadCloser, entry string) (io.ReadCloser, error) {
+	input, err := openInput(decompressor, entry)
+	if err != nil {
+		decompressor.Close()
+		return nil, err
+	}
+	return &compressedInput{ReadCloser: input, decompressor: decompressor}, nil
+}
+
+type compressedInput struct {
+	io.ReadCloser
+	decompressor io.Closer
+}
+
+func (input *compressedInput) Close() error {
+	input.decompressor.Close()
+	return input.ReadCloser.Close()
+}
+
+type zipInput struct {
+	io.Reader
+	spool *os.File
+	files []io.Closer
+}
+
+func (input *zipInput) Close() error {
+	for _, f := range input.files {
+		f.Close()
+	}
+	input.spool.Close()
+	return os.Remove(input.spool.Name())
+}
+
+// lastByteReader remembers the last byte read.
+type lastByteReader struct {
+	r    io.Reader
+	last byte
+}
+
+func (lr *lastByteReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		lr.last = p[n-1]
+	}
+	return n, err
+}
+
+// lineSeparator reads a line ending if the last file does not end with it.
+type lineSeparator struct {
+	last *lastByteReader
+	done bool
+}
+
+func (sep *lineSeparator) Read(p []byte) (int, error) {
+	if sep.done || sep.last.last == '\n' || sep.last.last == 0 {
+		return 0, io.EOF
+	}
+	sep.done = true
+	p[0] = '\n'
+	return 1, nil
+}
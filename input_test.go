@@ -0,0 +1,92 @@
+package gokenall
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func Test_openInput(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for _, f := range []struct{ name, content string }{
+		{"README.TXT", "readme"},
+		{"ADD_1909.CSV", "add1\r\nadd2"},
+		{"DEL_1909.CSV", "del1\r\n"},
+	} {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		input   []byte
+		entry   string
+		want    string
+		wantErr bool
+	}{
+		{"csv", []byte("01101,\"060  \"\r\n"), "", "01101,\"060  \"\r\n", false},
+		{"short", []byte("P"), "", "P", false},
+		{"all csv", archive.Bytes(), "", "add1\r\nadd2\ndel1\r\n", false},
+		{"entry", archive.Bytes(), "del_1909.csv", "del1\r\n", false},
+		{"no entry", archive.Bytes(), "KEN_ALL.CSV", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := openInput(bytes.NewReader(tt.input), tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openInput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer r.Close()
+			b, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("openInput() = %q, want %q", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_zip(t *testing.T) {
+	csv := "01101,\"060\",\"0600000\",\"ホッカイドウ\",\"サッポロシチュウオウク\",\"\",\"北海道\",\"札幌市中央区\",\"\",0,0,0,0,0,0"
+	list, err := Parse(bytes.NewReader(storedZip(t, "ken_all.csv", csv)))
+	if err != nil {
+		t.Fatalf("Parse() error = %+v", err)
+	}
+	if len(list) != 1 || list[0].ZipCode != "0600000" {
+		t.Errorf("Parse() = %v", list)
+	}
+
+	sjis, _ := japanese.ShiftJIS.NewEncoder().String("01101,\"060  \",\"0600000\",\"ﾎｯｶｲﾄﾞｳ\",\"ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ\",\"ｲｶﾆｹｲｻｲｶﾞﾅｲﾊﾞｱｲ\",\"北海道\",\"札幌市中央区\",\"以下に掲載がない場合\",0,0,0,0,0,0\r\n")
+	list, err = Parse(bytes.NewReader(storedZip(t, "KEN_ALL.CSV", sjis)))
+	if err != nil {
+		t.Fatalf("Parse() error = %+v", err)
+	}
+	if len(list) != 1 || list[0].Pref != "北海道" || list[0].PrefKana != "ﾎｯｶｲﾄﾞｳ" || list[0].Street != "以下に掲載がない場合" {
+		t.Errorf("Parse() of Shift_JIS = %v", list)
+	}
+
+	input, _ := japanese.ShiftJIS.NewEncoder().String("01101,\"060  \",\"0600000\",\"ﾎｯｶｲﾄﾞｳ\",\"ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ\",\"\",\"\",\"\",\"\",0,0,0,0,0,0\r\n")
+	var b strings.Builder
+	if err := Normalize(bytes.NewReader(storedZip(t, "KEN_ALL.CSV", input)), &b, NormalizeWidth|NormalizeUTF8|NormalizeTrim); err != nil {
+		t.Fatalf("Normalize() error = %+v", err)
+	}
+	if want := "01101,\"060\",\"0600000\",\"ホッカイドウ\",\"サッポロシチュウオウク\",\"\",\"\",\"\",\"\",0,0,0,0,0,0"; b.String() != want {
+		t.Errorf("Normalize() = %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,70 @@
+package gokenall
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// KanaForm is the form of the kana columns at normalize.
+type KanaForm string
+
+const (
+	// KanaHiragana is full-width hiragana like `さっぽろし`.
+	KanaHiragana KanaForm = "hiragana"
+	// KanaKatakana is full-width katakana like `サッポロシ`.
+	KanaKatakana KanaForm = "katakana"
+	// KanaHalfwidth is half-width katakana like `ｻｯﾎﾟﾛｼ` same as the original ken_all.csv.
+	KanaHalfwidth KanaForm = "halfwidth"
+)
+
+// KanaForms returns all kana forms in the order of the definition.
+func KanaForms() []KanaForm {
+	return []KanaForm{KanaHiragana, KanaKatakana, KanaHalfwidth}
+}
+
+func (form KanaForm) validate() error {
+	switch form {
+	case "", KanaHiragana, KanaKatakana, KanaHalfwidth:
+		return nil
+	}
+	return errors.Errorf("unknown kana form: %s", form)
+}
+
+// convert converts the kana text to the form.
+// The ascii letters are converted into hankaku in any form.
+func (form KanaForm) convert(s string) string {
+	switch form {
+	case KanaHiragana:
+		return strings.Map(katakana2Hiragana, foldWidth(strings.Map(hiragana2Katakana, s)))
+	case KanaKatakana:
+		return foldWidth(strings.Map(hiragana2Katakana, s))
+	case KanaHalfwidth:
+		narrow, _, _ := transform.String(transform.Chain(norm.NFD, width.Narrow, norm.NFC), strings.Map(hiragana2Katakana, s))
+		return narrow
+	}
+	return s
+}
+
+// foldWidth converts hankaku kana into zenkaku, zenkaku ascii letters into hankaku.
+func foldWidth(s string) string {
+	s, _, _ = transform.String(transform.Chain(norm.NFD, width.Fold, norm.NFC), s)
+	return s
+}
+
+func hiragana2Katakana(r rune) rune {
+	if 'ぁ' <= r && r <= 'ゖ' {
+		return r + ('ァ' - 'ぁ')
+	}
+	return r
+}
+
+func katakana2Hiragana(r rune) rune {
+	if 'ァ' <= r && r <= 'ヶ' {
+		return r - ('ァ' - 'ぁ')
+	}
+	return r
+}
@@ -0,0 +1,41 @@
+package gokenall
+
+import "testing"
+
+func TestKanaForm_convert(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		name string
+		form KanaForm
+		args args
+		want string
+	}{
+		{"none", "", args{"ｻｯﾎﾟﾛｼ"}, "ｻｯﾎﾟﾛｼ"},
+		{"hiragana", KanaHiragana, args{"ﾃﾝｼﾞﾝﾊﾞｼ7ﾁｮｳﾒ"}, "てんじんばし7ちょうめ"},
+		{"hiragana from katakana", KanaHiragana, args{"ヴィラセンター"}, "ゔぃらせんたー"},
+		{"katakana", KanaKatakana, args{"ﾃﾝｼﾞﾝﾊﾞｼ７ﾁｮｳﾒ"}, "テンジンバシ7チョウメ"},
+		{"katakana from hiragana", KanaKatakana, args{"さっぽろ"}, "サッポロ"},
+		{"halfwidth", KanaHalfwidth, args{"テンジンバシ７チョウメ"}, "ﾃﾝｼﾞﾝﾊﾞｼ7ﾁｮｳﾒ"},
+		{"halfwidth from hiragana", KanaHalfwidth, args{"ぱーく、いち"}, "ﾊﾟｰｸ､ｲﾁ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.form.convert(tt.args.s); got != tt.want {
+				t.Errorf("KanaForm.convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKanaForm_validate(t *testing.T) {
+	for _, form := range append(KanaForms(), "") {
+		if err := form.validate(); err != nil {
+			t.Errorf("KanaForm.validate() error = %v, form %q", err, form)
+		}
+	}
+	if err := KanaForm("romaji").validate(); err == nil {
+		t.Errorf("KanaForm.validate() error = nil, want error for unknown form")
+	}
+}
@@ -2,65 +2,42 @@ package gokenall
 
 import (
 	"fmt"
-	"regexp"
-	"strconv"
 	"strings"
 
-	"golang.org/x/text/width"
+	"github.com/pkg/errors"
 )
 
 type normalizer struct {
-	inputs               []*JapanZipCode
-	outputs              []*JapanZipCode
-	clearStreetReg       *regexp.Regexp
-	streetBlessReg       *regexp.Regexp
-	streetKanaBlessReg   *regexp.Regexp
-	streetInnerBlessReg1 *regexp.Regexp
-	streetInnerBlessReg2 *regexp.Regexp
-	streetInnerBlessReg3 *regexp.Regexp
-	streetInnerBlessReg4 *regexp.Regexp
-	streetInnerBlessReg5 *regexp.Regexp
-}
-
-const (
-	clearStreetRegExp       = `(^以下に掲載がない場合$|の次に番地がくる場合$|.+一円$)`
-	streetBlessRegExp       = `（([^）]+)）$`
-	streetKanaBlessRegExp   = `\(([^\)]+)\)$`
-	streetInnerBlessRegExp1 = `^(その他|地階・階層不明|.*を除く)$`
-	streetInnerBlessRegExp2 = `^([０１２３４５６７８９]+)階$`
-	streetInnerBlessRegExp3 = `^([０１２３４５６７８９]+)～([０１２３４５６７８９]+)(丁目|番地|番)$`
-	streetInnerBlessRegExp4 = `^([０１２３４５６７８９、]+)(丁目|番地|番)$`
-	streetInnerBlessRegExp5 = `^[^「」～－０１２３４５６７８９]+$`
-)
-
-const (
-	maxDevideNum = 99
-)
-
-var zen2hanMap = map[string]string{
-	"丁目": "ﾁｮｳﾒ",
-	"番地": "ﾊﾞﾝﾁ",
-	"番":  "ﾊﾞﾝ",
+	inputs         []*JapanZipCode
+	outputs        []*JapanZipCode
+	rules          []StreetRule
+	buildingStreet *JapanZipCode
+	audit          *Audit
+	trace          bool
+	rangeLimit     int
+	keepRange      bool
+	lines          int
 }
 
 func newNormalizer() *normalizer {
 	return &normalizer{
-		inputs:               make([]*JapanZipCode, 0),
-		outputs:              make([]*JapanZipCode, 0),
-		clearStreetReg:       regexp.MustCompile(clearStreetRegExp),
-		streetBlessReg:       regexp.MustCompile(streetBlessRegExp),
-		streetKanaBlessReg:   regexp.MustCompile(streetKanaBlessRegExp),
-		streetInnerBlessReg1: regexp.MustCompile(streetInnerBlessRegExp1),
-		streetInnerBlessReg2: regexp.MustCompile(streetInnerBlessRegExp2),
-		streetInnerBlessReg3: regexp.MustCompile(streetInnerBlessRegExp3),
-		streetInnerBlessReg4: regexp.MustCompile(streetInnerBlessRegExp4),
-		streetInnerBlessReg5: regexp.MustCompile(streetInnerBlessRegExp5),
+		inputs:     make([]*JapanZipCode, 0),
+		outputs:    make([]*JapanZipCode, 0),
+		rules:      defaultStreetRules(),
+		rangeLimit: DefaultRangeLimit,
 	}
 }
 
-func (normer *normalizer) push(p *JapanZipCode) {
+func (normer *normalizer) push(p *JapanZipCode) error {
+	normer.lines++
+	if normer.trace {
+		p.Trace = &Trace{
+			SourceLines:    []int{normer.lines},
+			OriginalStreet: p.Street,
+		}
+	}
 	normer.inputs = append(normer.inputs, p)
-	normer.normalize()
+	return normer.normalize()
 }
 
 func (normer *normalizer) canPop() bool {
@@ -76,89 +53,109 @@ func (normer *normalizer) pop() *JapanZipCode {
 	return ret
 }
 
-func (normer *normalizer) normalize() {
+// UnterminatedError is the error when the input ends while the multi-line row is not closed.
+type UnterminatedError struct {
+	Line int // 閉じ括弧のない行の開始行番号
+}
+
+func (e *UnterminatedError) Error() string {
+	return fmt.Sprintf("input ends while the multi-line row is not closed: start-line=%d", e.Line)
+}
+
+// flush outputs the buffered inputs of the unterminated multi-line row one by one without merging.
+// If strict is true, returns UnterminatedError instead.
+func (normer *normalizer) flush(strict bool) error {
 	if len(normer.inputs) == 0 {
-		return
+		return nil
+	}
+	if strict {
+		return &UnterminatedError{Line: normer.lines - len(normer.inputs) + 1}
+	}
+	for _, input := range normer.inputs {
+		outputs, err := normer.normalizeStreet(input)
+		if err != nil {
+			return err
+		}
+		normer.outputs = append(normer.outputs, outputs...)
+	}
+	normer.inputs = normer.inputs[:0]
+	return nil
+}
+
+func (normer *normalizer) normalize() error {
+	if len(normer.inputs) == 0 {
+		return nil
 	}
 
 	input := normer.inputs[0]
 	if input.isMultiLineStart() {
 		if ok := normer.normalizeMulti(); !ok {
-			return
+			return nil
 		}
 		input = normer.inputs[0]
 	}
 	normer.inputs = normer.inputs[1:]
 
-	outputs := normer.normalizeStreet(input)
+	outputs, err := normer.normalizeStreet(input)
+	if err != nil {
+		return err
+	}
 
 	normer.outputs = append(normer.outputs, outputs...)
-	return
+	return nil
 }
 
-func (normer *normalizer) normalizeStreet(input *JapanZipCode) []*JapanZipCode {
-	outputs := []*JapanZipCode{input}
-
-	if normer.clearStreetReg.FindString(outputs[0].Street) != "" {
-		outputs[0].Street = ""
-		outputs[0].StreetKana = ""
-	} else if matches := normer.streetBlessReg.FindStringSubmatch(outputs[0].Street); matches != nil {
+func (normer *normalizer) normalizeStreet(input *JapanZipCode) ([]*JapanZipCode, error) {
+	parts := splitStreet(input)
+	parts.rangeLimit = normer.rangeLimit
+	parts.keepRange = normer.keepRange
 
-		var innerBless, innerBlessKana string
-		innerBless = matches[1]
-
-		matchesKana := normer.streetKanaBlessReg.FindStringSubmatch(outputs[0].StreetKana)
-		if len(matchesKana) > 1 {
-			innerBlessKana = matchesKana[1]
+	for _, rule := range normer.rules {
+		outputs, ok, err := rule.Apply(input, parts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to apply street rule: rule=%s, street=%s", rule.Name(), input.Street)
 		}
-
-		outputs[0].Street = normer.streetBlessReg.ReplaceAllString(outputs[0].Street, "")
-		outputs[0].StreetKana = normer.streetKanaBlessReg.ReplaceAllString(outputs[0].StreetKana, "")
-
-		if normer.streetInnerBlessReg1.FindString(innerBless) != "" { // `^(その他|地階・階層不明|.*を除く)$`
-			// Do nothing
-		} else if matches := normer.streetInnerBlessReg2.FindStringSubmatch(innerBless); matches != nil { // `^([０１２３４５６７８９]+)階$`
-			outputs[0].Street += matches[1] + "階"
-			outputs[0].StreetKana += width.Narrow.String(matches[1]) + "ｶｲ"
-		} else if matches := normer.streetInnerBlessReg3.FindStringSubmatch(innerBless); matches != nil { // `^([０１２３４５６７８９]+)～([０１２３４５６７８９]+)(丁目|番地|番)$`
-			start := zenkaku2Int(matches[1])
-			end := zenkaku2Int(matches[2])
-			if (end - start + 1) <= maxDevideNum {
-				for i := start; i <= end; i++ {
-					ad := *outputs[0]
-					ad.Street += int2Zenkaku(i) + matches[3]
-					ad.StreetKana += fmt.Sprintf("%d%s", i, zen2hanMap[matches[3]])
-					outputs = append(outputs, &ad)
-				}
-				outputs = outputs[1:]
-			}
-		} else if matches := normer.streetInnerBlessReg4.FindStringSubmatch(innerBless); matches != nil { // `^([０１２３４５６７８９、]+)(丁目|番地|番)$`
-			splits := strings.Split(matches[1], "、")
-			for _, s := range splits {
-				ad := *outputs[0]
-				ad.Street += s + matches[2]
-				ad.StreetKana += fmt.Sprintf("%d%s", zenkaku2Int(s), zen2hanMap[matches[2]])
-				outputs = append(outputs, &ad)
+		if ok {
+			for _, output := range outputs {
+				normer.setBuilding(output)
+				output.Trace = input.Trace.withRule(rule.Name())
 			}
-			outputs = outputs[1:]
-		} else if normer.streetInnerBlessReg5.FindString(innerBless) != "" { // `^[^「」～－０１２３４５６７８９]+$`
-			splits := strings.Split(innerBless, "、")
-			splitsKana := strings.Split(innerBlessKana, "､")
-			if len(splits) == len(splitsKana) {
-				for i := range splits {
-					ad := *outputs[0]
-					ad.Street += splits[i]
-					ad.StreetKana += splitsKana[i]
-					outputs = append(outputs, &ad)
-				}
-				outputs = outputs[1:]
-			}
-		} else {
-			//fmt.Println(innerBless)
+			return outputs, nil
 		}
 	}
 
-	return outputs
+	if parts.Inner == "" {
+		return []*JapanZipCode{input}, nil
+	}
+
+	if normer.audit != nil {
+		normer.audit.record(input, parts.Inner)
+	}
+	output := parts.Row(input, "", "")
+	output.StreetNote = parts.Inner
+	output.StreetNoteKana = parts.InnerKana
+	output.StreetNoteType = StreetNoteOther
+	if strings.Contains(parts.Inner, "を除く") {
+		output.StreetNoteType = StreetNoteExclusion
+	}
+	output.Trace = input.Trace.withRule(unhandledRuleName)
+	return []*JapanZipCode{output}, nil
+}
+
+// setBuilding removes the town name from the building name of p.
+// The town name is taken from the last street which has `（次のビルを除く）` in the same city.
+func (normer *normalizer) setBuilding(p *JapanZipCode) {
+	if p.StreetNote == "次のビルを除く" {
+		normer.buildingStreet = p
+		return
+	}
+
+	b := normer.buildingStreet
+	if p.Building == "" || b == nil || b.JISCode != p.JISCode || len(p.Building) <= len(b.Street) || !strings.HasPrefix(p.Building, b.Street) {
+		return
+	}
+	p.Building = strings.TrimPrefix(p.Building, b.Street)
+	p.BuildingKana = strings.TrimPrefix(p.BuildingKana, b.StreetKana)
 }
 
 func (normer *normalizer) normalizeMulti() bool {
@@ -182,19 +179,45 @@ func (normer *normalizer) normalizeMulti() bool {
 	normer.inputs[endIndex].Street = street.String()
 	normer.inputs[endIndex].StreetKana = streetKana.String()
 
+	if normer.trace {
+		lines := []int{}
+		for i := 0; i <= endIndex; i++ {
+			lines = append(lines, normer.inputs[i].Trace.SourceLines...)
+		}
+		normer.inputs[endIndex].Trace = &Trace{
+			SourceLines:    lines,
+			Rules:          []string{mergeRuleName},
+			OriginalStreet: street.String(),
+		}
+	}
+
 	normer.inputs = normer.inputs[endIndex:]
 
 	return true
 }
 
-func zenkaku2Int(t string) int {
-	i, err := strconv.ParseInt(width.Narrow.String(t), 10, 32)
-	if err != nil {
-		panic(err)
+// splitOutside splits s by sep except where sep is enclosed by open and close.
+func splitOutside(s, sep, open, close string) []string {
+	splits := []string{}
+	depth := 0
+	last := 0
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], open):
+			depth++
+			i += len(open)
+		case strings.HasPrefix(s[i:], close):
+			if depth > 0 {
+				depth--
+			}
+			i += len(close)
+		case depth == 0 && strings.HasPrefix(s[i:], sep):
+			splits = append(splits, s[last:i])
+			i += len(sep)
+			last = i
+		default:
+			i++
+		}
 	}
-	return int(i)
-}
-
-func int2Zenkaku(i int) string {
-	return width.Widen.String(strconv.FormatInt(int64(i), 10))
+	return append(splits, s[last:])
 }
@@ -74,44 +74,102 @@ func Test_normalizer_pop(t *testing.T) {
 
 func Test_normalizer_normalize(t *testing.T) {
 
-	beforeAfter := [][][]string{
+	beforeAfter := []struct {
+		option NormalizeOption
+		before []string
+		after  []string
+	}{
 		{
-			{`01101,"060  ","0600000","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","ｲｶﾆｹｲｻｲｶﾞﾅｲﾊﾞｱｲ","北海道","札幌市中央区","以下に掲載がない場合",0,0,0,0,0,0`},
-			{`01101,"060  ","0600000","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","","北海道","札幌市中央区","",0,0,0,0,0,0`},
+			NormalizeStreetNote,
+			[]string{
+				`01101,"060  ","0600000","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","ｲｶﾆｹｲｻｲｶﾞﾅｲﾊﾞｱｲ","北海道","札幌市中央区","以下に掲載がない場合",0,0,0,0,0,0`,
+			},
+			[]string{
+				`01101,"060  ","0600000","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","","北海道","札幌市中央区","",0,0,0,0,0,0,"以下に掲載がない場合","ｲｶﾆｹｲｻｲｶﾞﾅｲﾊﾞｱｲ",catch_all`,
+			},
 		},
 		{
-			{`08546,"30604","3060433","ｲﾊﾞﾗｷｹﾝ","ｻｼﾏｸﾞﾝｻｶｲﾏﾁ","ｻｶｲﾏﾁﾉﾂｷﾞﾆﾊﾞﾝﾁｶﾞｸﾙﾊﾞｱｲ","茨城県","猿島郡境町","境町の次に番地がくる場合",0,0,0,0,0,0`},
-			{`08546,"30604","3060433","ｲﾊﾞﾗｷｹﾝ","ｻｼﾏｸﾞﾝｻｶｲﾏﾁ","","茨城県","猿島郡境町","",0,0,0,0,0,0`},
+			NormalizeStreetNote,
+			[]string{
+				`08546,"30604","3060433","ｲﾊﾞﾗｷｹﾝ","ｻｼﾏｸﾞﾝｻｶｲﾏﾁ","ｻｶｲﾏﾁﾉﾂｷﾞﾆﾊﾞﾝﾁｶﾞｸﾙﾊﾞｱｲ","茨城県","猿島郡境町","境町の次に番地がくる場合",0,0,0,0,0,0`,
+			},
+			[]string{
+				`08546,"30604","3060433","ｲﾊﾞﾗｷｹﾝ","ｻｼﾏｸﾞﾝｻｶｲﾏﾁ","","茨城県","猿島郡境町","",0,0,0,0,0,0,"境町の次に番地がくる場合","ｻｶｲﾏﾁﾉﾂｷﾞﾆﾊﾞﾝﾁｶﾞｸﾙﾊﾞｱｲ",other`,
+			},
 		},
 		{
-			{`13362,"10003","1000301","ﾄｳｷｮｳﾄ","ﾄｼﾏﾑﾗ","ﾄｼﾏﾑﾗｲﾁｴﾝ","東京都","利島村","利島村一円",0,0,0,0,0,0`},
-			{`13362,"10003","1000301","ﾄｳｷｮｳﾄ","ﾄｼﾏﾑﾗ","","東京都","利島村","",0,0,0,0,0,0`},
+			NormalizeStreetNote,
+			[]string{
+				`13362,"10003","1000301","ﾄｳｷｮｳﾄ","ﾄｼﾏﾑﾗ","ﾄｼﾏﾑﾗｲﾁｴﾝ","東京都","利島村","利島村一円",0,0,0,0,0,0`,
+			},
+			[]string{
+				`13362,"10003","1000301","ﾄｳｷｮｳﾄ","ﾄｼﾏﾑﾗ","","東京都","利島村","",0,0,0,0,0,0,"利島村一円","ﾄｼﾏﾑﾗｲﾁｴﾝ",other`,
+			},
 		},
 		{
-			{`25443,"52203","5220317","ｼｶﾞｹﾝ","ｲﾇｶﾐｸﾞﾝﾀｶﾞﾁｮｳ","ｲﾁｴﾝ","滋賀県","犬上郡多賀町","一円",0,0,0,0,0,0`},
-			{`25443,"52203","5220317","ｼｶﾞｹﾝ","ｲﾇｶﾐｸﾞﾝﾀｶﾞﾁｮｳ","ｲﾁｴﾝ","滋賀県","犬上郡多賀町","一円",0,0,0,0,0,0`},
+			NoNormalizeOption,
+			[]string{
+				`25443,"52203","5220317","ｼｶﾞｹﾝ","ｲﾇｶﾐｸﾞﾝﾀｶﾞﾁｮｳ","ｲﾁｴﾝ","滋賀県","犬上郡多賀町","一円",0,0,0,0,0,0`,
+			},
+			[]string{
+				`25443,"52203","5220317","ｼｶﾞｹﾝ","ｲﾇｶﾐｸﾞﾝﾀｶﾞﾁｮｳ","ｲﾁｴﾝ","滋賀県","犬上郡多賀町","一円",0,0,0,0,0,0`,
+			},
 		},
 		{
-			{`26103,"606  ","6060017","ｷｮｳﾄﾌ","ｷｮｳﾄｼｻｷｮｳｸ","ｲﾜｸﾗｱｸﾞﾗﾁｮｳ(ｿﾉﾀ)","京都府","京都市左京区","岩倉上蔵町（その他）",1,0,0,0,0,0`},
-			{`26103,"606  ","6060017","ｷｮｳﾄﾌ","ｷｮｳﾄｼｻｷｮｳｸ","ｲﾜｸﾗｱｸﾞﾗﾁｮｳ","京都府","京都市左京区","岩倉上蔵町",1,0,0,0,0,0`},
+			NormalizeStreetNote,
+			[]string{
+				`26103,"606  ","6060017","ｷｮｳﾄﾌ","ｷｮｳﾄｼｻｷｮｳｸ","ｲﾜｸﾗｱｸﾞﾗﾁｮｳ(ｿﾉﾀ)","京都府","京都市左京区","岩倉上蔵町（その他）",1,0,0,0,0,0`,
+			},
+			[]string{
+				`26103,"606  ","6060017","ｷｮｳﾄﾌ","ｷｮｳﾄｼｻｷｮｳｸ","ｲﾜｸﾗｱｸﾞﾗﾁｮｳ","京都府","京都市左京区","岩倉上蔵町",1,0,0,0,0,0,"その他","ｿﾉﾀ",catch_all`,
+			},
 		},
 		{
-			{`27119,"545  ","5456090","ｵｵｻｶﾌ","ｵｵｻｶｼｱﾍﾞﾉｸ","ｱﾍﾞﾉｽｼﾞｱﾍﾞﾉﾊﾙｶｽ(ﾁｶｲ･ｶｲｿｳﾌﾒｲ)","大阪府","大阪市阿倍野区","阿倍野筋あべのハルカス（地階・階層不明）",0,0,0,0,0,0`},
-			{`27119,"545  ","5456090","ｵｵｻｶﾌ","ｵｵｻｶｼｱﾍﾞﾉｸ","ｱﾍﾞﾉｽｼﾞｱﾍﾞﾉﾊﾙｶｽ","大阪府","大阪市阿倍野区","阿倍野筋あべのハルカス",0,0,0,0,0,0`},
+			NormalizeStreetNote | NormalizeBuilding,
+			[]string{
+				`27119,"545  ","5456090","ｵｵｻｶﾌ","ｵｵｻｶｼｱﾍﾞﾉｸ","ｱﾍﾞﾉｽｼﾞｱﾍﾞﾉﾊﾙｶｽ(ﾁｶｲ･ｶｲｿｳﾌﾒｲ)","大阪府","大阪市阿倍野区","阿倍野筋あべのハルカス（地階・階層不明）",0,0,0,0,0,0`,
+			},
+			[]string{
+				`27119,"545  ","5456090","ｵｵｻｶﾌ","ｵｵｻｶｼｱﾍﾞﾉｸ","ｱﾍﾞﾉｽｼﾞｱﾍﾞﾉﾊﾙｶｽ","大阪府","大阪市阿倍野区","阿倍野筋あべのハルカス",0,0,0,0,0,0,"地階・階層不明","ﾁｶｲ･ｶｲｿｳﾌﾒｲ",floor,"阿倍野筋あべのハルカス","ｱﾍﾞﾉｽｼﾞｱﾍﾞﾉﾊﾙｶｽ","地階・階層不明"`,
+			},
 		},
 		{
-			{`27119,"545  ","5450052","ｵｵｻｶﾌ","ｵｵｻｶｼｱﾍﾞﾉｸ","ｱﾍﾞﾉｽｼﾞ(ﾂｷﾞﾉﾋﾞﾙｦﾉｿﾞｸ)","大阪府","大阪市阿倍野区","阿倍野筋（次のビルを除く）",0,0,1,0,0,0`},
-			{`27119,"545  ","5450052","ｵｵｻｶﾌ","ｵｵｻｶｼｱﾍﾞﾉｸ","ｱﾍﾞﾉｽｼﾞ","大阪府","大阪市阿倍野区","阿倍野筋",0,0,1,0,0,0`},
+			NormalizeStreetNote,
+			[]string{
+				`27119,"545  ","5450052","ｵｵｻｶﾌ","ｵｵｻｶｼｱﾍﾞﾉｸ","ｱﾍﾞﾉｽｼﾞ(ﾂｷﾞﾉﾋﾞﾙｦﾉｿﾞｸ)","大阪府","大阪市阿倍野区","阿倍野筋（次のビルを除く）",0,0,1,0,0,0`,
+			},
+			[]string{
+				`27119,"545  ","5450052","ｵｵｻｶﾌ","ｵｵｻｶｼｱﾍﾞﾉｸ","ｱﾍﾞﾉｽｼﾞ","大阪府","大阪市阿倍野区","阿倍野筋",0,0,1,0,0,0,"次のビルを除く","ﾂｷﾞﾉﾋﾞﾙｦﾉｿﾞｸ",exclusion`,
+			},
 		},
 		{
-			{`27119,"545  ","5456060","ｵｵｻｶﾌ","ｵｵｻｶｼｱﾍﾞﾉｸ","ｱﾍﾞﾉｽｼﾞｱﾍﾞﾉﾊﾙｶｽ(60ｶｲ)","大阪府","大阪市阿倍野区","阿倍野筋あべのハルカス（６０階）",0,0,0,0,0,0`},
-			{`27119,"545  ","5456060","ｵｵｻｶﾌ","ｵｵｻｶｼｱﾍﾞﾉｸ","ｱﾍﾞﾉｽｼﾞｱﾍﾞﾉﾊﾙｶｽ60ｶｲ","大阪府","大阪市阿倍野区","阿倍野筋あべのハルカス６０階",0,0,0,0,0,0`},
+			NormalizeStreetNote | NormalizeBuilding,
+			[]string{
+				`27119,"545  ","5456060","ｵｵｻｶﾌ","ｵｵｻｶｼｱﾍﾞﾉｸ","ｱﾍﾞﾉｽｼﾞｱﾍﾞﾉﾊﾙｶｽ(60ｶｲ)","大阪府","大阪市阿倍野区","阿倍野筋あべのハルカス（６０階）",0,0,0,0,0,0`,
+			},
+			[]string{
+				`27119,"545  ","5456060","ｵｵｻｶﾌ","ｵｵｻｶｼｱﾍﾞﾉｸ","ｱﾍﾞﾉｽｼﾞｱﾍﾞﾉﾊﾙｶｽ60ｶｲ","大阪府","大阪市阿倍野区","阿倍野筋あべのハルカス６０階",0,0,0,0,0,0,"","",,"阿倍野筋あべのハルカス","ｱﾍﾞﾉｽｼﾞｱﾍﾞﾉﾊﾙｶｽ","60"`,
+			},
 		},
 		{
-			{
+			NormalizeStreetNote | NormalizeBuilding,
+			[]string{
+				`13104,"160  ","1600023","ﾄｳｷｮｳﾄ","ｼﾝｼﾞｭｸｸ","ﾆｼｼﾝｼﾞｭｸ(ﾂｷﾞﾉﾋﾞﾙｦﾉｿﾞｸ)","東京都","新宿区","西新宿（次のビルを除く）",0,0,1,0,0,0`,
+				`13104,"163  ","1630690","ﾄｳｷｮｳﾄ","ｼﾝｼﾞｭｸｸ","ﾆｼｼﾝｼﾞｭｸｼﾝｼﾞｭｸｾﾝﾀｰﾋﾞﾙ(ﾁｶｲ･ｶｲｿｳﾌﾒｲ)","東京都","新宿区","西新宿新宿センタービル（地階・階層不明）",0,0,0,0,0,0`,
+				`13104,"163  ","1630601","ﾄｳｷｮｳﾄ","ｼﾝｼﾞｭｸｸ","ﾆｼｼﾝｼﾞｭｸｼﾝｼﾞｭｸｾﾝﾀｰﾋﾞﾙ(1ｶｲ)","東京都","新宿区","西新宿新宿センタービル（１階）",0,0,0,0,0,0`,
+			},
+			[]string{
+				`13104,"160  ","1600023","ﾄｳｷｮｳﾄ","ｼﾝｼﾞｭｸｸ","ﾆｼｼﾝｼﾞｭｸ","東京都","新宿区","西新宿",0,0,1,0,0,0,"次のビルを除く","ﾂｷﾞﾉﾋﾞﾙｦﾉｿﾞｸ",exclusion,"","",""`,
+				`13104,"163  ","1630690","ﾄｳｷｮｳﾄ","ｼﾝｼﾞｭｸｸ","ﾆｼｼﾝｼﾞｭｸｼﾝｼﾞｭｸｾﾝﾀｰﾋﾞﾙ","東京都","新宿区","西新宿新宿センタービル",0,0,0,0,0,0,"地階・階層不明","ﾁｶｲ･ｶｲｿｳﾌﾒｲ",floor,"新宿センタービル","ｼﾝｼﾞｭｸｾﾝﾀｰﾋﾞﾙ","地階・階層不明"`,
+				`13104,"163  ","1630601","ﾄｳｷｮｳﾄ","ｼﾝｼﾞｭｸｸ","ﾆｼｼﾝｼﾞｭｸｼﾝｼﾞｭｸｾﾝﾀｰﾋﾞﾙ1ｶｲ","東京都","新宿区","西新宿新宿センタービル１階",0,0,0,0,0,0,"","",,"新宿センタービル","ｼﾝｼﾞｭｸｾﾝﾀｰﾋﾞﾙ","1"`,
+			},
+		},
+		{
+			NoNormalizeOption,
+			[]string{
 				`27127,"530  ","5300041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ(1-6ﾁｮｳﾒ)","大阪府","大阪市北区","天神橋（１～６丁目）",1,0,1,0,0,0`,
 			},
-			{
+			[]string{
 				`27127,"530  ","5300041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ1ﾁｮｳﾒ","大阪府","大阪市北区","天神橋１丁目",1,0,1,0,0,0`,
 				`27127,"530  ","5300041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ2ﾁｮｳﾒ","大阪府","大阪市北区","天神橋２丁目",1,0,1,0,0,0`,
 				`27127,"530  ","5300041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ3ﾁｮｳﾒ","大阪府","大阪市北区","天神橋３丁目",1,0,1,0,0,0`,
@@ -121,19 +179,21 @@ func Test_normalizer_normalize(t *testing.T) {
 			},
 		},
 		{
-			{
+			NoNormalizeOption,
+			[]string{
 				`27127,"531  ","5310041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ(7､8ﾁｮｳﾒ)","大阪府","大阪市北区","天神橋（７、８丁目）",1,0,1,0,0,0`,
 			},
-			{
+			[]string{
 				`27127,"531  ","5310041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ7ﾁｮｳﾒ","大阪府","大阪市北区","天神橋７丁目",1,0,1,0,0,0`,
 				`27127,"531  ","5310041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ8ﾁｮｳﾒ","大阪府","大阪市北区","天神橋８丁目",1,0,1,0,0,0`,
 			},
 		},
 		{
-			{
+			NoNormalizeOption,
+			[]string{
 				`01575,"04957","0495731","ﾎｯｶｲﾄﾞｳ","ｳｽｸﾞﾝｿｳﾍﾞﾂﾁｮｳ","ﾄｳﾔｺｵﾝｾﾝ(1-7ﾊﾞﾝﾁ)","北海道","有珠郡壮瞥町","洞爺湖温泉（１～７番地）",1,0,0,0,0,0`,
 			},
-			{
+			[]string{
 				`01575,"04957","0495731","ﾎｯｶｲﾄﾞｳ","ｳｽｸﾞﾝｿｳﾍﾞﾂﾁｮｳ","ﾄｳﾔｺｵﾝｾﾝ1ﾊﾞﾝﾁ","北海道","有珠郡壮瞥町","洞爺湖温泉１番地",1,0,0,0,0,0`,
 				`01575,"04957","0495731","ﾎｯｶｲﾄﾞｳ","ｳｽｸﾞﾝｿｳﾍﾞﾂﾁｮｳ","ﾄｳﾔｺｵﾝｾﾝ2ﾊﾞﾝﾁ","北海道","有珠郡壮瞥町","洞爺湖温泉２番地",1,0,0,0,0,0`,
 				`01575,"04957","0495731","ﾎｯｶｲﾄﾞｳ","ｳｽｸﾞﾝｿｳﾍﾞﾂﾁｮｳ","ﾄｳﾔｺｵﾝｾﾝ3ﾊﾞﾝﾁ","北海道","有珠郡壮瞥町","洞爺湖温泉３番地",1,0,0,0,0,0`,
@@ -144,20 +204,22 @@ func Test_normalizer_normalize(t *testing.T) {
 			},
 		},
 		{
-			{
+			NoNormalizeOption,
+			[]string{
 				`01604,"05922","0592253","ﾎｯｶｲﾄﾞｳ","ﾆｲｶｯﾌﾟｸﾞﾝﾆｲｶｯﾌﾟﾁｮｳ","ｵｵｶﾘﾍﾞ(436､516､567ﾊﾞﾝﾁ)","北海道","新冠郡新冠町","大狩部（４３６、５１６、５６７番地）",1,0,0,0,0,0`,
 			},
-			{
+			[]string{
 				`01604,"05922","0592253","ﾎｯｶｲﾄﾞｳ","ﾆｲｶｯﾌﾟｸﾞﾝﾆｲｶｯﾌﾟﾁｮｳ","ｵｵｶﾘﾍﾞ436ﾊﾞﾝﾁ","北海道","新冠郡新冠町","大狩部４３６番地",1,0,0,0,0,0`,
 				`01604,"05922","0592253","ﾎｯｶｲﾄﾞｳ","ﾆｲｶｯﾌﾟｸﾞﾝﾆｲｶｯﾌﾟﾁｮｳ","ｵｵｶﾘﾍﾞ516ﾊﾞﾝﾁ","北海道","新冠郡新冠町","大狩部５１６番地",1,0,0,0,0,0`,
 				`01604,"05922","0592253","ﾎｯｶｲﾄﾞｳ","ﾆｲｶｯﾌﾟｸﾞﾝﾆｲｶｯﾌﾟﾁｮｳ","ｵｵｶﾘﾍﾞ567ﾊﾞﾝﾁ","北海道","新冠郡新冠町","大狩部５６７番地",1,0,0,0,0,0`,
 			},
 		},
 		{
-			{
+			NoNormalizeOption,
+			[]string{
 				`44201,"870  ","8700923","ｵｵｲﾀｹﾝ","ｵｵｲﾀｼ","ﾀｶｼﾞｮｳﾆｼﾏﾁ(1-7ﾊﾞﾝ)","大分県","大分市","高城西町（１～７番）",1,0,0,0,0,0`,
 			},
-			{
+			[]string{
 				`44201,"870  ","8700923","ｵｵｲﾀｹﾝ","ｵｵｲﾀｼ","ﾀｶｼﾞｮｳﾆｼﾏﾁ1ﾊﾞﾝ","大分県","大分市","高城西町１番",1,0,0,0,0,0`,
 				`44201,"870  ","8700923","ｵｵｲﾀｹﾝ","ｵｵｲﾀｼ","ﾀｶｼﾞｮｳﾆｼﾏﾁ2ﾊﾞﾝ","大分県","大分市","高城西町２番",1,0,0,0,0,0`,
 				`44201,"870  ","8700923","ｵｵｲﾀｹﾝ","ｵｵｲﾀｼ","ﾀｶｼﾞｮｳﾆｼﾏﾁ3ﾊﾞﾝ","大分県","大分市","高城西町３番",1,0,0,0,0,0`,
@@ -168,10 +230,108 @@ func Test_normalizer_normalize(t *testing.T) {
 			},
 		},
 		{
-			{
+			NoNormalizeOption,
+			[]string{
+				`03302,"02844","0284301","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｴｶﾘ(ﾀﾞｲ1ﾁﾜﾘ-ﾀﾞｲ3ﾁﾜﾘ)","岩手県","岩手郡葛巻町","江刈（第１地割～第３地割）",1,1,0,0,0,0`,
+			},
+			[]string{
+				`03302,"02844","0284301","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｴｶﾘﾀﾞｲ1ﾁﾜﾘ","岩手県","岩手郡葛巻町","江刈第１地割",1,1,0,0,0,0`,
+				`03302,"02844","0284301","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｴｶﾘﾀﾞｲ2ﾁﾜﾘ","岩手県","岩手郡葛巻町","江刈第２地割",1,1,0,0,0,0`,
+				`03302,"02844","0284301","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｴｶﾘﾀﾞｲ3ﾁﾜﾘ","岩手県","岩手郡葛巻町","江刈第３地割",1,1,0,0,0,0`,
+			},
+		},
+		{
+			NormalizeStreetNote,
+			[]string{
+				`03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷ(ﾀﾞｲ40ﾁﾜﾘ<57ﾊﾞﾝﾁ125､176ｦﾉｿﾞｸ>-ﾀﾞｲ41ﾁﾜﾘ)","岩手県","岩手郡葛巻町","葛巻（第４０地割「５７番地１２５、１７６を除く」～第４１地割）",1,1,0,0,0,0`,
+			},
+			[]string{
+				`03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷﾀﾞｲ40ﾁﾜﾘ","岩手県","岩手郡葛巻町","葛巻第４０地割",1,1,0,0,0,0,"５７番地１２５、１７６を除く","57ﾊﾞﾝﾁ125､176ｦﾉｿﾞｸ",exclusion`,
+				`03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷﾀﾞｲ41ﾁﾜﾘ","岩手県","岩手郡葛巻町","葛巻第４１地割",1,1,0,0,0,0,"","",`,
+			},
+		},
+		{
+			NoNormalizeOption,
+			[]string{
+				`03366,"02955","0295523","ｲﾜﾃｹﾝ","ﾜｶﾞｸﾞﾝﾆｼﾜｶﾞﾏﾁ","ｴｯﾁｭｳﾊﾀ(1ﾁﾜﾘ)","岩手県","和賀郡西和賀町","越中畑（１地割）",1,1,0,0,0,0`,
+			},
+			[]string{
+				`03366,"02955","0295523","ｲﾜﾃｹﾝ","ﾜｶﾞｸﾞﾝﾆｼﾜｶﾞﾏﾁ","ｴｯﾁｭｳﾊﾀ1ﾁﾜﾘ","岩手県","和賀郡西和賀町","越中畑１地割",1,1,0,0,0,0`,
+			},
+		},
+		{
+			NoNormalizeOption,
+			[]string{
+				`13101,"100  ","1000000","ﾄｳｷｮｳﾄ","ﾁﾖﾀﾞｸ","ﾃｽﾄ(1ﾁｮｳﾒ-3ﾁｮｳﾒ)","東京都","千代田区","テスト（一丁目～三丁目）",0,0,1,0,0,0`,
+			},
+			[]string{
+				`13101,"100  ","1000000","ﾄｳｷｮｳﾄ","ﾁﾖﾀﾞｸ","ﾃｽﾄ1ﾁｮｳﾒ","東京都","千代田区","テスト一丁目",0,0,1,0,0,0`,
+				`13101,"100  ","1000000","ﾄｳｷｮｳﾄ","ﾁﾖﾀﾞｸ","ﾃｽﾄ2ﾁｮｳﾒ","東京都","千代田区","テスト二丁目",0,0,1,0,0,0`,
+				`13101,"100  ","1000000","ﾄｳｷｮｳﾄ","ﾁﾖﾀﾞｸ","ﾃｽﾄ3ﾁｮｳﾒ","東京都","千代田区","テスト三丁目",0,0,1,0,0,0`,
+			},
+		},
+		{
+			NoNormalizeOption,
+			[]string{
+				`13101,"100  ","1000001","ﾄｳｷｮｳﾄ","ﾁﾖﾀﾞｸ","ﾃｽﾄ(19-21ﾊﾞﾝﾁ)","東京都","千代田区","テスト（十九～二十一番地）",0,0,1,0,0,0`,
+			},
+			[]string{
+				`13101,"100  ","1000001","ﾄｳｷｮｳﾄ","ﾁﾖﾀﾞｸ","ﾃｽﾄ19ﾊﾞﾝﾁ","東京都","千代田区","テスト十九番地",0,0,1,0,0,0`,
+				`13101,"100  ","1000001","ﾄｳｷｮｳﾄ","ﾁﾖﾀﾞｸ","ﾃｽﾄ20ﾊﾞﾝﾁ","東京都","千代田区","テスト二十番地",0,0,1,0,0,0`,
+				`13101,"100  ","1000001","ﾄｳｷｮｳﾄ","ﾁﾖﾀﾞｸ","ﾃｽﾄ21ﾊﾞﾝﾁ","東京都","千代田区","テスト二十一番地",0,0,1,0,0,0`,
+			},
+		},
+		{
+			NoNormalizeOption,
+			[]string{
+				`01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ(1-3ﾁｮｳﾒ､5ﾁｮｳﾒ)","北海道","札幌市南区","真駒内（１～３丁目、５丁目）",0,0,1,0,0,0`,
+			},
+			[]string{
+				`01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ1ﾁｮｳﾒ","北海道","札幌市南区","真駒内１丁目",0,0,1,0,0,0`,
+				`01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ2ﾁｮｳﾒ","北海道","札幌市南区","真駒内２丁目",0,0,1,0,0,0`,
+				`01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ3ﾁｮｳﾒ","北海道","札幌市南区","真駒内３丁目",0,0,1,0,0,0`,
+				`01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ5ﾁｮｳﾒ","北海道","札幌市南区","真駒内５丁目",0,0,1,0,0,0`,
+			},
+		},
+		{
+			NoNormalizeOption,
+			[]string{
+				`01604,"05922","0592254","ﾎｯｶｲﾄﾞｳ","ﾆｲｶｯﾌﾟｸﾞﾝﾆｲｶｯﾌﾟﾁｮｳ","ﾋﾞﾎﾞｳ(103-105ﾊﾞﾝﾁ､200ﾊﾞﾝﾁ)","北海道","新冠郡新冠町","美宝（１０３～１０５番地、２００番地）",1,0,0,0,0,0`,
+			},
+			[]string{
+				`01604,"05922","0592254","ﾎｯｶｲﾄﾞｳ","ﾆｲｶｯﾌﾟｸﾞﾝﾆｲｶｯﾌﾟﾁｮｳ","ﾋﾞﾎﾞｳ103ﾊﾞﾝﾁ","北海道","新冠郡新冠町","美宝１０３番地",1,0,0,0,0,0`,
+				`01604,"05922","0592254","ﾎｯｶｲﾄﾞｳ","ﾆｲｶｯﾌﾟｸﾞﾝﾆｲｶｯﾌﾟﾁｮｳ","ﾋﾞﾎﾞｳ104ﾊﾞﾝﾁ","北海道","新冠郡新冠町","美宝１０４番地",1,0,0,0,0,0`,
+				`01604,"05922","0592254","ﾎｯｶｲﾄﾞｳ","ﾆｲｶｯﾌﾟｸﾞﾝﾆｲｶｯﾌﾟﾁｮｳ","ﾋﾞﾎﾞｳ105ﾊﾞﾝﾁ","北海道","新冠郡新冠町","美宝１０５番地",1,0,0,0,0,0`,
+				`01604,"05922","0592254","ﾎｯｶｲﾄﾞｳ","ﾆｲｶｯﾌﾟｸﾞﾝﾆｲｶｯﾌﾟﾁｮｳ","ﾋﾞﾎﾞｳ200ﾊﾞﾝﾁ","北海道","新冠郡新冠町","美宝２００番地",1,0,0,0,0,0`,
+			},
+		},
+		{
+			NoNormalizeOption,
+			[]string{
+				`03366,"02955","0295524","ｲﾜﾃｹﾝ","ﾜｶﾞｸﾞﾝﾆｼﾜｶﾞﾏﾁ","ｻﾜｳﾁ(ﾀﾞｲ1ﾁﾜﾘ-ﾀﾞｲ2ﾁﾜﾘ､ﾀﾞｲ4ﾁﾜﾘ)","岩手県","和賀郡西和賀町","沢内（第１地割～第２地割、第４地割）",1,1,0,0,0,0`,
+			},
+			[]string{
+				`03366,"02955","0295524","ｲﾜﾃｹﾝ","ﾜｶﾞｸﾞﾝﾆｼﾜｶﾞﾏﾁ","ｻﾜｳﾁﾀﾞｲ1ﾁﾜﾘ","岩手県","和賀郡西和賀町","沢内第１地割",1,1,0,0,0,0`,
+				`03366,"02955","0295524","ｲﾜﾃｹﾝ","ﾜｶﾞｸﾞﾝﾆｼﾜｶﾞﾏﾁ","ｻﾜｳﾁﾀﾞｲ2ﾁﾜﾘ","岩手県","和賀郡西和賀町","沢内第２地割",1,1,0,0,0,0`,
+				`03366,"02955","0295524","ｲﾜﾃｹﾝ","ﾜｶﾞｸﾞﾝﾆｼﾜｶﾞﾏﾁ","ｻﾜｳﾁﾀﾞｲ4ﾁﾜﾘ","岩手県","和賀郡西和賀町","沢内第４地割",1,1,0,0,0,0`,
+			},
+		},
+		{
+			NoNormalizeOption,
+			[]string{
+				`01202,"040  ","0400001","ﾎｯｶｲﾄﾞｳ","ﾊｺﾀﾞﾃｼ","ｱｻﾋﾁｮｳ(<ﾋｶﾞｼ>､<ﾆｼ>)","北海道","函館市","旭町（「東」、「西」）",0,0,0,0,0,0`,
+			},
+			[]string{
+				`01202,"040  ","0400001","ﾎｯｶｲﾄﾞｳ","ﾊｺﾀﾞﾃｼ","ｱｻﾋﾁｮｳﾋｶﾞｼ","北海道","函館市","旭町東",0,0,0,0,0,0`,
+				`01202,"040  ","0400001","ﾎｯｶｲﾄﾞｳ","ﾊｺﾀﾞﾃｼ","ｱｻﾋﾁｮｳﾆｼ","北海道","函館市","旭町西",0,0,0,0,0,0`,
+			},
+		},
+		{
+			NoNormalizeOption,
+			[]string{
 				`01214,"09845","0984581","ﾎｯｶｲﾄﾞｳ","ﾜｯｶﾅｲｼ","ﾊﾞｯｶｲﾑﾗ(ｶﾐﾕｳﾁ､ｼﾓﾕｳﾁ､ﾕｳｸﾙ､ｵﾈﾄﾏﾅｲ)","北海道","稚内市","抜海村（上勇知、下勇知、夕来、オネトマナイ）",1,0,0,0,0,0`,
 			},
-			{
+			[]string{
 				`01214,"09845","0984581","ﾎｯｶｲﾄﾞｳ","ﾜｯｶﾅｲｼ","ﾊﾞｯｶｲﾑﾗｶﾐﾕｳﾁ","北海道","稚内市","抜海村上勇知",1,0,0,0,0,0`,
 				`01214,"09845","0984581","ﾎｯｶｲﾄﾞｳ","ﾜｯｶﾅｲｼ","ﾊﾞｯｶｲﾑﾗｼﾓﾕｳﾁ","北海道","稚内市","抜海村下勇知",1,0,0,0,0,0`,
 				`01214,"09845","0984581","ﾎｯｶｲﾄﾞｳ","ﾜｯｶﾅｲｼ","ﾊﾞｯｶｲﾑﾗﾕｳｸﾙ","北海道","稚内市","抜海村夕来",1,0,0,0,0,0`,
@@ -179,40 +339,44 @@ func Test_normalizer_normalize(t *testing.T) {
 			},
 		},
 		{
-			{
+			NoNormalizeOption,
+			[]string{
 				`01104,"003  ","0030022","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼｼﾛｲｼｸ","ﾅﾝｺﾞｳﾄﾞｵﾘ(ﾐﾅﾐ)","北海道","札幌市白石区","南郷通（南）",1,0,0,0,0,0`,
 			},
-			{
+			[]string{
 				`01104,"003  ","0030022","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼｼﾛｲｼｸ","ﾅﾝｺﾞｳﾄﾞｵﾘﾐﾅﾐ","北海道","札幌市白石区","南郷通南",1,0,0,0,0,0`,
 			},
 		},
 		{
-			{
+			NormalizeStreetNote,
+			[]string{
 				`04101,"980  ","9800065","ﾐﾔｷﾞｹﾝ","ｾﾝﾀﾞｲｼｱｵﾊﾞｸ","ﾂﾁﾄｲ(1ﾁｮｳﾒ<11ｦﾉｿﾞｸ>)","宮城県","仙台市青葉区","土樋（１丁目「１１を除く」）",0,0,1,0,0,0`,
 			},
-			{
-				`04101,"980  ","9800065","ﾐﾔｷﾞｹﾝ","ｾﾝﾀﾞｲｼｱｵﾊﾞｸ","ﾂﾁﾄｲ","宮城県","仙台市青葉区","土樋",0,0,1,0,0,0`,
+			[]string{
+				`04101,"980  ","9800065","ﾐﾔｷﾞｹﾝ","ｾﾝﾀﾞｲｼｱｵﾊﾞｸ","ﾂﾁﾄｲ","宮城県","仙台市青葉区","土樋",0,0,1,0,0,0,"１丁目「１１を除く」","1ﾁｮｳﾒ<11ｦﾉｿﾞｸ>",exclusion`,
 			},
 		},
 		{
-			{
+			NormalizeStreetNote,
+			[]string{
 				`01224,"066  ","0660005","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","ｷｮｳﾜ(88-2､271-10､343-2､404-1､427-","北海道","千歳市","協和（８８－２、２７１－１０、３４３－２、４０４－１、４２７－",1,0,0,0,0,0`,
 				`01224,"066  ","0660005","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","3､431-12､443-6､608-2､641-8､814､842-","北海道","千歳市","３、４３１－１２、４４３－６、６０８－２、６４１－８、８１４、８４２－",1,0,0,0,0,0`,
 				`01224,"066  ","0660005","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","5､1137-3､1392､1657､1752ﾊﾞﾝﾁ)","北海道","千歳市","５、１１３７－３、１３９２、１６５７、１７５２番地）",1,0,0,0,0,0`,
 				`01224,"06911","0691182","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","ｷｮｳﾜ(ｿﾉﾀ)","北海道","千歳市","協和（その他）",1,0,0,0,0,0`,
 			},
-			{
-				`01224,"066  ","0660005","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","ｷｮｳﾜ","北海道","千歳市","協和",1,0,0,0,0,0`,
-				`01224,"06911","0691182","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","ｷｮｳﾜ","北海道","千歳市","協和",1,0,0,0,0,0`,
+			[]string{
+				`01224,"066  ","0660005","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","ｷｮｳﾜ","北海道","千歳市","協和",1,0,0,0,0,0,"８８－２、２７１－１０、３４３－２、４０４－１、４２７－３、４３１－１２、４４３－６、６０８－２、６４１－８、８１４、８４２－５、１１３７－３、１３９２、１６５７、１７５２番地","88-2､271-10､343-2､404-1､427-3､431-12､443-6､608-2､641-8､814､842-5､1137-3､1392､1657､1752ﾊﾞﾝﾁ",other`,
+				`01224,"06911","0691182","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","ｷｮｳﾜ","北海道","千歳市","協和",1,0,0,0,0,0,"その他","ｿﾉﾀ",catch_all`,
 			},
 		},
 		{
-			{
+			NoNormalizeOption,
+			[]string{
 				`40206,"826  ","8260043","ﾌｸｵｶｹﾝ","ﾀｶﾞﾜｼ","ﾅﾗ(ｱｵﾊﾞﾁｮｳ､ｵｵｳﾗ､ｶｲｼｬﾏﾁ､ｶｽﾐｶﾞｵｶ､ｺﾞﾄｳｼﾞﾆｼﾀﾞﾝﾁ､ｺﾞﾄｳｼﾞﾋｶﾞｼﾀﾞﾝﾁ､ﾉｿﾞﾐｶﾞｵｶ､","福岡県","田川市","奈良（青葉町、大浦、会社町、霞ケ丘、後藤寺西団地、後藤寺東団地、希望ケ丘、",0,0,0,0,0,0`,
 				`40206,"826  ","8260043","ﾌｸｵｶｹﾝ","ﾀｶﾞﾜｼ","ﾏﾂﾉｷ､ﾐﾂｲｺﾞﾄｳｼﾞ､ﾐﾄﾞﾘﾏﾁ､ﾂｷﾐｶﾞｵｶ)","福岡県","田川市","松の木、三井後藤寺、緑町、月見ケ丘）",0,0,0,0,0,0`,
 				`40206,"826  ","8260024","ﾌｸｵｶｹﾝ","ﾀｶﾞﾜｼ","ﾆｼﾎﾝﾏﾁ","福岡県","田川市","西本町",0,0,0,0,0,0`,
 			},
-			{
+			[]string{
 				`40206,"826  ","8260043","ﾌｸｵｶｹﾝ","ﾀｶﾞﾜｼ","ﾅﾗｱｵﾊﾞﾁｮｳ","福岡県","田川市","奈良青葉町",0,0,0,0,0,0`,
 				`40206,"826  ","8260043","ﾌｸｵｶｹﾝ","ﾀｶﾞﾜｼ","ﾅﾗｵｵｳﾗ","福岡県","田川市","奈良大浦",0,0,0,0,0,0`,
 				`40206,"826  ","8260043","ﾌｸｵｶｹﾝ","ﾀｶﾞﾜｼ","ﾅﾗｶｲｼｬﾏﾁ","福岡県","田川市","奈良会社町",0,0,0,0,0,0`,
@@ -237,12 +401,15 @@ func Test_normalizer_normalize(t *testing.T) {
 	tests := []*testT{}
 	for _, ba := range beforeAfter {
 		tt := &testT{"", []*JapanZipCode{}, []*JapanZipCode{}}
-		for _, before := range ba[0] {
+		for _, before := range ba.before {
 			jzc, _ := parseCSV(before, false)
 			tt.pushes = append(tt.pushes, jzc)
 		}
-		for _, after := range ba[1] {
-			jzc, _ := parseCSV(after, false)
+		for _, after := range ba.after {
+			jzc, err := parseCSVWithOption(after, ba.option)
+			if err != nil {
+				t.Fatalf("parseCSVWithOption() error = %v", err)
+			}
 			tt.pops = append(tt.pops, jzc)
 		}
 		tests = append(tests, tt)
@@ -263,41 +430,79 @@ func Test_normalizer_normalize(t *testing.T) {
 	}
 }
 
-func Test_zenkaku2Int(t *testing.T) {
-	type args struct {
-		t string
+func Test_normalizer_flush(t *testing.T) {
+	inputs := []string{
+		`01101,"064  ","0640941","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","ｱｻﾋｶﾞｵｶ","北海道","札幌市中央区","旭ケ丘",0,0,1,0,0,0`,
+		`01224,"066  ","0660005","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","ｷｮｳﾜ(88-2､271-10､343-2､404-1､427-","北海道","千歳市","協和（８８－２、２７１－１０、３４３－２、４０４－１、４２７－",1,0,0,0,0,0`,
+		`01224,"066  ","0660005","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","3､431-12､443-6､608-2､641-8､814､842-","北海道","千歳市","３、４３１－１２、４４３－６、６０８－２、６４１－８、８１４、８４２－",1,0,0,0,0,0`,
 	}
-	tests := []struct {
-		name string
-		args args
-		want int
-	}{
-		{"", args{"１２３４５６７８９０"}, 1234567890},
+	push := func() *normalizer {
+		normer := newNormalizer()
+		for _, input := range inputs {
+			p, _ := parseCSV(input, false)
+			normer.push(p)
+		}
+		return normer
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := zenkaku2Int(tt.args.t); got != tt.want {
-				t.Errorf("zenkaku2Int() = %v, want %v", got, tt.want)
+
+	t.Run("strict", func(t *testing.T) {
+		normer := push()
+		normer.pop()
+		err := normer.flush(true)
+		if e, ok := err.(*UnterminatedError); !ok || e.Line != 2 {
+			t.Errorf("normalizer.flush() error = %v, want UnterminatedError at line 2", err)
+		}
+		if normer.canPop() {
+			t.Errorf("normalizer.canPop() = true, want false")
+		}
+	})
+
+	t.Run("not strict", func(t *testing.T) {
+		normer := push()
+		normer.pop()
+		if err := normer.flush(false); err != nil {
+			t.Errorf("normalizer.flush() error = %v, want nil", err)
+		}
+		for _, input := range inputs[1:] {
+			want, _ := parseCSV(input, false)
+			if got := normer.pop(); !reflect.DeepEqual(got, want) {
+				t.Errorf("normalizer.pop() = %v, want %v", got, want)
 			}
-		})
-	}
+		}
+		if normer.canPop() {
+			t.Errorf("normalizer.canPop() = true, want false")
+		}
+	})
+
+	t.Run("terminated", func(t *testing.T) {
+		normer := newNormalizer()
+		if err := normer.flush(true); err != nil {
+			t.Errorf("normalizer.flush() error = %v, want nil", err)
+		}
+	})
 }
 
-func Test_int2Zenkaku(t *testing.T) {
+func Test_splitOutside(t *testing.T) {
 	type args struct {
-		i int
+		s     string
+		sep   string
+		open  string
+		close string
 	}
 	tests := []struct {
 		name string
 		args args
-		want string
+		want []string
 	}{
-		{"", args{1234567890}, "１２３４５６７８９０"},
+		{"", args{"", "、", "「", "」"}, []string{""}},
+		{"", args{"１、２", "、", "「", "」"}, []string{"１", "２"}},
+		{"", args{"１「２、３」、４", "、", "「", "」"}, []string{"１「２、３」", "４"}},
+		{"", args{"1<2､3>､4", "､", "<", ">"}, []string{"1<2､3>", "4"}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := int2Zenkaku(tt.args.i); got != tt.want {
-				t.Errorf("int2Zenkaku() = %v, want %v", got, tt.want)
+			if got := splitOutside(tt.args.s, tt.args.sep, tt.args.open, tt.args.close); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitOutside() = %v, want %v", got, tt.want)
 			}
 		})
 	}
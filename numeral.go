@@ -0,0 +1,104 @@
+package gokenall
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/width"
+)
+
+const (
+	// numeralChars is the characters of the numerals in the street names
+	numeralChars = "０１２３４５６７８９〇一二三四五六七八九十百千"
+)
+
+var (
+	kanjiDigits      = []rune("〇一二三四五六七八九")
+	kanjiMultipliers = map[rune]int{'十': 10, '百': 100, '千': 1000}
+)
+
+// numeral2Int converts the numeral to int.
+// The numeral is zenkaku digits like `２３`, kanji numerals like `二十三` or `二三`,
+// or mixed forms like `２十３`.
+func numeral2Int(t string) (int, error) {
+	if t == "" {
+		return 0, errors.New("invalid numeral: empty")
+	}
+
+	var digits strings.Builder
+	total, current, run := 0, 0, 0
+	lastMultiplier := 0
+	for _, r := range width.Narrow.String(t) {
+		if d := numeralDigit(r); d >= 0 {
+			digits.WriteRune(rune('0' + d))
+			current = current*10 + d
+			run++
+			continue
+		}
+		m, ok := kanjiMultipliers[r]
+		if !ok {
+			return 0, errors.Errorf("invalid numeral: %s", t)
+		}
+		if lastMultiplier != 0 && m >= lastMultiplier || run > 1 {
+			return 0, errors.Errorf("invalid numeral: %s", t)
+		}
+		if run == 0 {
+			current = 1
+		}
+		total += current * m
+		current, run = 0, 0
+		lastMultiplier = m
+	}
+
+	if lastMultiplier == 0 {
+		i, err := strconv.ParseInt(digits.String(), 10, 32)
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid numeral: %s", t)
+		}
+		return int(i), nil
+	}
+	if run > 1 {
+		return 0, errors.Errorf("invalid numeral: %s", t)
+	}
+	return total + current, nil
+}
+
+// int2Numeral converts i to the numeral in the same form as like.
+// If like has kanji numerals, returns kanji numerals like `二十三`. Otherwise returns zenkaku digits like `２３`.
+func int2Numeral(i int, like string) string {
+	if i <= 0 || i >= 10000 || !strings.ContainsAny(like, "〇一二三四五六七八九十百千") {
+		return width.Widen.String(strconv.FormatInt(int64(i), 10))
+	}
+
+	var b strings.Builder
+	for _, unit := range []struct {
+		n int
+		s string
+	}{{1000, "千"}, {100, "百"}, {10, "十"}} {
+		d := i / unit.n
+		if d > 1 {
+			b.WriteRune(kanjiDigits[d])
+		}
+		if d > 0 {
+			b.WriteString(unit.s)
+		}
+		i %= unit.n
+	}
+	if i > 0 {
+		b.WriteRune(kanjiDigits[i])
+	}
+	return b.String()
+}
+
+func numeralDigit(r rune) int {
+	if '0' <= r && r <= '9' {
+		return int(r - '0')
+	}
+	for i, d := range kanjiDigits {
+		if r == d {
+			return i
+		}
+	}
+	return -1
+}
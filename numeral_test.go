@@ -0,0 +1,67 @@
+package gokenall
+
+import "testing"
+
+func Test_numeral2Int(t *testing.T) {
+	type args struct {
+		t string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    int
+		wantErr bool
+	}{
+		{"zenkaku", args{"１２３４５６７８９０"}, 1234567890, false},
+		{"kanji", args{"一"}, 1, false},
+		{"kanji tens", args{"二十三"}, 23, false},
+		{"kanji hundred", args{"百"}, 100, false},
+		{"kanji thousands", args{"二千十五"}, 2015, false},
+		{"kanji positional", args{"二〇"}, 20, false},
+		{"mixed", args{"２十３"}, 23, false},
+		{"empty", args{""}, 0, true},
+		{"not numeral", args{"１Ａ"}, 0, true},
+		{"overflow", args{"９９９９９９９９９９９"}, 0, true},
+		{"repeated multiplier", args{"十十"}, 0, true},
+		{"ascending multiplier", args{"十百"}, 0, true},
+		{"digits before multiplier", args{"二三十"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := numeral2Int(tt.args.t)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("numeral2Int() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("numeral2Int() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_int2Numeral(t *testing.T) {
+	type args struct {
+		i    int
+		like string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"zenkaku", args{1234567890, "１"}, "１２３４５６７８９０"},
+		{"kanji", args{3, "一"}, "三"},
+		{"kanji tens", args{21, "十九"}, "二十一"},
+		{"kanji hundreds", args{110, "一"}, "百十"},
+		{"kanji thousands", args{2015, "一"}, "二千十五"},
+		{"kanji too large", args{10000, "一"}, "１００００"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := int2Numeral(tt.args.i, tt.args.like); got != tt.want {
+				t.Errorf("int2Numeral() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
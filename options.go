@@ -0,0 +1,236 @@
+package gokenall
+
+import (
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// NormalizeOptions is the options at normalize.
+// Start from DefaultNormalizeOptions and change the fields you want.
+type NormalizeOptions struct {
+	Width      bool     // 文字幅を揃える（半角カナ→全角カナ、全角英数→半角）
+	UTF8       bool     // sjis から utf8 に変換する
+	Trim       bool     // 各項目の前後の空白を除去する
+	StreetNote bool     // 町域名から除去した注記の列を追加する
+	Building   bool     // 高層ビルのビル名と階の列を追加する
+	Trace      bool     // 元データの行番号・適用したルール・加工前の町域名の列を追加する
+	Strict     bool     // 複数行の途中で入力が終わった場合に *UnterminatedError を返す
+	KeepRange  bool     // 範囲を分割せずに1行で残し、番号の範囲の列を追加する
+	RangeLimit int      // 範囲を分割する最大の行数（0 は DefaultRangeLimit）
+	Rules      []string // 適用する町域名のルール名（nil は StreetRuleNames の全てのルール）
+	Audit      *Audit   // 加工しきれなかった括弧内の文字の記録先（nil は記録しない）
+
+	Encoding        Encoding    // 出力の文字コード（空の場合は UTF8 で決める）
+	LineEnding      LineEnding  // 出力の改行コード（空の場合は LF）
+	FinalLineEnding bool        // 最終行の後にも改行を出力する
+	Kana            KanaForm    // カナ項目の文字種（空の場合は Width で決める）
+	Romaji          bool        // カナ項目から変換したローマ字の列を追加する
+	RomajiStyle     RomajiStyle // ローマ字の表記方法
+	SearchKey       bool        // 町域名の検索キーの列を追加する
+	Compression     Compression // 出力の圧縮形式（空の場合は圧縮しない）
+
+	Entry string // 入力が zip の場合に読み込むファイル名（空の場合は全ての csv）
+}
+
+// Encoding is the text encoding of the output at normalize.
+type Encoding string
+
+const (
+	// EncodingSJIS is Shift_JIS. It is written as CP932 (Windows-31J) same as EncodingCP932
+	// since the original ken_all.csv uses the characters of Windows-31J like ① and 髙.
+	EncodingSJIS Encoding = "sjis"
+	// EncodingCP932 is CP932 (Windows-31J). It is an alias of EncodingSJIS.
+	EncodingCP932 Encoding = "cp932"
+	// EncodingEUCJP is EUC-JP of JIS X 0208 and JIS X 0212.
+	// The characters of Windows-31J not in them like ①, ㈱ and 髙 cannot be written,
+	// and NormalizeWithOptions returns an error without writing anything.
+	EncodingEUCJP Encoding = "eucjp"
+	// EncodingUTF8 is UTF-8 without BOM.
+	EncodingUTF8 Encoding = "utf8"
+	// EncodingUTF8BOM is UTF-8 with BOM.
+	EncodingUTF8BOM Encoding = "utf8bom"
+)
+
+// Encodings returns all encodings in the order of the definition.
+func Encodings() []Encoding {
+	return []Encoding{EncodingSJIS, EncodingCP932, EncodingEUCJP, EncodingUTF8, EncodingUTF8BOM}
+}
+
+// encoder returns the transformer from UTF-8 to the encoding. nil for UTF-8.
+func (encoding Encoding) encoder() (transform.Transformer, error) {
+	switch encoding {
+	case EncodingSJIS, EncodingCP932:
+		return japanese.ShiftJIS.NewEncoder(), nil
+	case EncodingEUCJP:
+		return japanese.EUCJP.NewEncoder(), nil
+	case EncodingUTF8:
+		return nil, nil
+	case EncodingUTF8BOM:
+		return unicode.UTF8BOM.NewEncoder(), nil
+	}
+	return nil, errors.Errorf("unknown encoding: %s", encoding)
+}
+
+// eucJPChecker finds the characters EUC-JP cannot represent, caching the result of each character.
+// japanese.EUCJP encodes the extensions of Windows-31J like ① and 髙 into the rows
+// JIS X 0208 does not define, which other EUC-JP decoders cannot read.
+type eucJPChecker map[rune]bool
+
+// unsupported returns the first character in s EUC-JP cannot represent.
+func (checker eucJPChecker) unsupported(s string) (rune, bool) {
+	if checker == nil {
+		return 0, false
+	}
+	for _, c := range s {
+		if c < utf8.RuneSelf {
+			continue
+		}
+		ok, found := checker[c]
+		if !found {
+			b, _, err := transform.String(japanese.EUCJP.NewEncoder(), string(c))
+			// 2 bytes in the rows 1-8 and 16-84 of JIS X 0208, 0x8E for hankaku kana or 0x8F for JIS X 0212
+			ok = err == nil && (len(b) != 2 || b[0] == 0x8e || b[0] <= 0xa8 || 0xb0 <= b[0] && b[0] <= 0xf4)
+			checker[c] = ok
+		}
+		if !ok {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// LineEnding is the line separator of the output at normalize.
+type LineEnding string
+
+const (
+	// LineEndingLF is "\n".
+	LineEndingLF LineEnding = "\n"
+	// LineEndingCRLF is "\r\n".
+	LineEndingCRLF LineEnding = "\r\n"
+)
+
+// DefaultNormalizeOptions returns the options same as DefaultNormalizeOption.
+func DefaultNormalizeOptions() *NormalizeOptions {
+	return DefaultNormalizeOption.Options()
+}
+
+// Options converts the flags to NormalizeOptions.
+func (option NormalizeOption) Options() *NormalizeOptions {
+	return &NormalizeOptions{
+		Width:      option&NormalizeWidth != 0,
+		UTF8:       option&NormalizeUTF8 != 0,
+		Trim:       option&NormalizeTrim != 0,
+		StreetNote: option&NormalizeStreetNote != 0,
+		Building:   option&NormalizeBuilding != 0,
+		Trace:      option&NormalizeTrace != 0,
+		Strict:     option&NormalizeStrict != 0,
+		KeepRange:  option&NormalizeRange != 0,
+		Romaji:     option&NormalizeRomaji != 0,
+		SearchKey:  option&NormalizeSearchKey != 0,
+	}
+}
+
+// option converts the options to the flags.
+func (opts *NormalizeOptions) option() NormalizeOption {
+	option := NoNormalizeOption
+	for flag, set := range map[NormalizeOption]bool{
+		NormalizeWidth:      opts.Width,
+		NormalizeUTF8:       opts.UTF8,
+		NormalizeTrim:       opts.Trim,
+		NormalizeStreetNote: opts.StreetNote,
+		NormalizeBuilding:   opts.Building,
+		NormalizeTrace:      opts.Trace,
+		NormalizeStrict:     opts.Strict,
+		NormalizeRange:      opts.KeepRange,
+		NormalizeRomaji:     opts.Romaji,
+		NormalizeSearchKey:  opts.SearchKey,
+	} {
+		if set {
+			option |= flag
+		}
+	}
+	return option
+}
+
+func (opts *NormalizeOptions) newNormalizer() (*normalizer, error) {
+	normer := newNormalizer()
+	if opts.Rules != nil {
+		rules, err := lookupStreetRules(opts.Rules)
+		if err != nil {
+			return nil, err
+		}
+		if len(rules) == 0 {
+			return nil, errors.New("no street rule is selected")
+		}
+		normer.rules = rules
+	}
+	if opts.RangeLimit < 0 {
+		return nil, errors.Errorf("range limit must be positive: %d", opts.RangeLimit)
+	}
+	if opts.RangeLimit > 0 {
+		normer.rangeLimit = opts.RangeLimit
+	}
+	normer.audit = opts.Audit
+	normer.trace = opts.Trace
+	normer.keepRange = opts.KeepRange
+	return normer, nil
+}
+
+func (opts *NormalizeOptions) encoding() Encoding {
+	if opts.Encoding != "" {
+		return opts.Encoding
+	}
+	if opts.UTF8 {
+		return EncodingUTF8
+	}
+	return EncodingSJIS
+}
+
+func (opts *NormalizeOptions) lineEnding() (string, error) {
+	switch opts.LineEnding {
+	case "":
+		return string(LineEndingLF), nil
+	case LineEndingLF, LineEndingCRLF:
+		return string(opts.LineEnding), nil
+	}
+	return "", errors.Errorf("unknown line ending: %q", opts.LineEnding)
+}
+
+// convert makes a copy of p whose texts are converted by Width and Kana, and the romaji and search key are set.
+func (opts *NormalizeOptions) convert(p *JapanZipCode) *JapanZipCode {
+	if !opts.Width && opts.Kana == "" && !opts.Romaji && !opts.SearchKey {
+		return p
+	}
+	row := *p
+	if opts.SearchKey {
+		row.StreetSearchKey = SearchKey(p.Street)
+	}
+	if opts.Romaji {
+		row.PrefRoma = KanaToRomaji(p.PrefKana, opts.RomajiStyle)
+		row.CityRoma = KanaToRomaji(p.CityKana, opts.RomajiStyle)
+		row.StreetRoma = KanaToRomaji(p.StreetKana, opts.RomajiStyle)
+	}
+	if opts.Width {
+		for _, s := range []*string{
+			&row.PrefKana, &row.CityKana, &row.StreetKana, &row.Pref, &row.City, &row.Street,
+			&row.StreetNote, &row.StreetNoteKana, &row.Building, &row.BuildingKana, &row.Floor, &row.StreetNumberUnit,
+		} {
+			*s = foldWidth(*s)
+		}
+		if row.Trace != nil {
+			trace := *row.Trace
+			trace.OriginalStreet = foldWidth(trace.OriginalStreet)
+			row.Trace = &trace
+		}
+	}
+	if opts.Kana != "" {
+		for _, s := range []*string{&row.PrefKana, &row.CityKana, &row.StreetKana, &row.StreetNoteKana, &row.BuildingKana} {
+			*s = opts.Kana.convert(*s)
+		}
+	}
+	return &row
+}
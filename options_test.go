@@ -0,0 +1,231 @@
+package gokenall
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestNormalizeOption_Options(t *testing.T) {
+	tests := []struct {
+		name   string
+		option NormalizeOption
+		want   *NormalizeOptions
+	}{
+		{"no option", NoNormalizeOption, &NormalizeOptions{}},
+		{"default", DefaultNormalizeOption, &NormalizeOptions{Width: true, UTF8: true, Trim: true}},
+		{"all", AllNormalizeOption, &NormalizeOptions{Width: true, UTF8: true, Trim: true}},
+		{"every flag", NormalizeOption(1<<bitsNormalizeOption - 1), &NormalizeOptions{Width: true, UTF8: true, Trim: true, StreetNote: true, Building: true, Trace: true, Strict: true, KeepRange: true, Romaji: true, SearchKey: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.option.Options()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeOption.Options() = %v, want %v", got, tt.want)
+			}
+			if option := got.option(); option != tt.option {
+				t.Errorf("NormalizeOptions.option() = %v, want %v", option, tt.option)
+			}
+		})
+	}
+}
+
+func TestNormalizeWithOptions(t *testing.T) {
+	input, _ := japanese.ShiftJIS.NewEncoder().String(
+		`01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ(1-3ﾁｮｳﾒ)","北海道","札幌市南区","真駒内（１～３丁目）",0,0,1,0,0,0`)
+
+	tests := []struct {
+		name    string
+		opts    *NormalizeOptions
+		want    string
+		wantErr bool
+	}{
+		{"nil", nil, strings.Join([]string{
+			`01106,"005","0050011","ホッカイドウ","サッポロシミナミク","マコマナイ1チョウメ","北海道","札幌市南区","真駒内1丁目",0,0,1,0,0,0`,
+			`01106,"005","0050011","ホッカイドウ","サッポロシミナミク","マコマナイ2チョウメ","北海道","札幌市南区","真駒内2丁目",0,0,1,0,0,0`,
+			`01106,"005","0050011","ホッカイドウ","サッポロシミナミク","マコマナイ3チョウメ","北海道","札幌市南区","真駒内3丁目",0,0,1,0,0,0`,
+		}, "\n"), false},
+		{"range limit", &NormalizeOptions{UTF8: true, Trim: true, StreetNote: true, RangeLimit: 2}, strings.Join([]string{
+			`01106,"005","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ","北海道","札幌市南区","真駒内",0,0,1,0,0,0,"１～３丁目","1-3ﾁｮｳﾒ",other`,
+		}, "\n"), false},
+		{"rules", &NormalizeOptions{UTF8: true, Trim: true, Rules: []string{"clear"}}, strings.Join([]string{
+			`01106,"005","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ","北海道","札幌市南区","真駒内",0,0,1,0,0,0`,
+		}, "\n"), false},
+		{"unknown rule", &NormalizeOptions{Rules: []string{"unknown"}}, "", true},
+		{"empty rules", &NormalizeOptions{Rules: []string{}}, "", true},
+		{"negative range limit", &NormalizeOptions{RangeLimit: -1}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w bytes.Buffer
+			err := NormalizeWithOptions(strings.NewReader(input), &w, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got := w.String(); !tt.wantErr && got != tt.want {
+				t.Errorf("NormalizeWithOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeWithOptions_encoding(t *testing.T) {
+	input, _ := japanese.ShiftJIS.NewEncoder().String(strings.Join([]string{
+		`01101,"064  ","0640941","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","ｱｻﾋｶﾞｵｶ","北海道","札幌市中央区","旭ケ丘",0,0,1,0,0,0`,
+		`01101,"060  ","0600041","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","ｵｵﾄﾞｵﾘﾋｶﾞｼ","北海道","札幌市中央区","大通東",0,0,1,0,0,0`,
+	}, "\r\n"))
+	lines := []string{
+		`01101,"064","0640941","ホッカイドウ","サッポロシチュウオウク","アサヒガオカ","北海道","札幌市中央区","旭ケ丘",0,0,1,0,0,0`,
+		`01101,"060","0600041","ホッカイドウ","サッポロシチュウオウク","オオドオリヒガシ","北海道","札幌市中央区","大通東",0,0,1,0,0,0`,
+	}
+	sjis, _ := japanese.ShiftJIS.NewEncoder().String(strings.Join(lines, "\n"))
+	eucjp, _ := japanese.EUCJP.NewEncoder().String(strings.Join(lines, "\n"))
+
+	tests := []struct {
+		name       string
+		encoding   Encoding
+		lineEnding LineEnding
+		final      bool
+		want       string
+		wantErr    bool
+	}{
+		{"utf8", EncodingUTF8, "", false, strings.Join(lines, "\n"), false},
+		{"utf8bom", EncodingUTF8BOM, "", false, "\ufeff" + strings.Join(lines, "\n"), false},
+		{"sjis", EncodingSJIS, "", false, sjis, false},
+		{"cp932", EncodingCP932, "", false, sjis, false},
+		{"eucjp", EncodingEUCJP, "", false, eucjp, false},
+		{"crlf", EncodingUTF8, LineEndingCRLF, false, strings.Join(lines, "\r\n"), false},
+		{"final line ending", EncodingUTF8, LineEndingCRLF, true, strings.Join(lines, "\r\n") + "\r\n", false},
+		{"unknown encoding", Encoding("latin1"), "", false, "", true},
+		{"unknown line ending", EncodingUTF8, LineEnding("\r"), false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultNormalizeOptions()
+			opts.Encoding = tt.encoding
+			opts.LineEnding = tt.lineEnding
+			opts.FinalLineEnding = tt.final
+
+			var w bytes.Buffer
+			err := NormalizeWithOptions(strings.NewReader(input), &w, opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got := w.String(); !tt.wantErr && got != tt.want {
+				t.Errorf("NormalizeWithOptions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeWithOptions_eucjp(t *testing.T) {
+	tests := []struct {
+		name   string
+		street string
+	}{
+		{"circled digit", "テスト①"},
+		{"parenthesized ideograph", "㈱テスト"},
+		{"ibm extension", "髙田"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := japanese.ShiftJIS.NewEncoder().String(strings.Join([]string{
+				`01101,"064  ","0640941","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","ｱｻﾋｶﾞｵｶ","北海道","札幌市中央区","旭ケ丘",0,0,1,0,0,0`,
+				`01101,"060  ","0600041","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","ﾃｽﾄ","北海道","札幌市中央区","` + tt.street + `",0,0,1,0,0,0`,
+			}, "\r\n"))
+			if err != nil {
+				t.Fatalf("ShiftJIS.NewEncoder().String() error = %v", err)
+			}
+			opts := DefaultNormalizeOptions()
+			opts.Encoding = EncodingEUCJP
+
+			var w bytes.Buffer
+			if err := NormalizeWithOptions(strings.NewReader(input), &w, opts); err == nil {
+				t.Errorf("NormalizeWithOptions() error = nil, want error")
+			}
+			if w.Len() != 0 {
+				t.Errorf("NormalizeWithOptions() wrote %q, want nothing", w.String())
+			}
+		})
+	}
+}
+
+func Test_eucJPChecker_unsupported(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		want  rune
+		found bool
+	}{
+		{"ascii", "0640941", 0, false},
+		{"jis x 0208", "北海道札幌市中央区～－", 0, false},
+		{"hankaku kana", "ﾎｯｶｲﾄﾞｳ", 0, false},
+		{"jis x 0212", "丂", 0, false},
+		{"nec special", "テスト①", '①', true},
+		{"ibm extension", "髙田", '髙', true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := eucJPChecker{}.unsupported(tt.s)
+			if got != tt.want || found != tt.found {
+				t.Errorf("eucJPChecker.unsupported() = %q, %v, want %q, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestNormalizeWithOptions_kana(t *testing.T) {
+	input, _ := japanese.ShiftJIS.NewEncoder().String(
+		`27127,"530  ","5300041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ(7ﾁｮｳﾒ)","大阪府","大阪市北区","天神橋（７丁目）",0,0,1,0,0,0`)
+
+	tests := []struct {
+		name  string
+		width bool
+		kana  KanaForm
+		want  string
+	}{
+		{"width", true, "", `27127,"530","5300041","オオサカフ","オオサカシキタク","テンジンバシ7チョウメ","大阪府","大阪市北区","天神橋7丁目",0,0,1,0,0,0`},
+		{"hiragana", true, KanaHiragana, `27127,"530","5300041","おおさかふ","おおさかしきたく","てんじんばし7ちょうめ","大阪府","大阪市北区","天神橋7丁目",0,0,1,0,0,0`},
+		{"halfwidth", true, KanaHalfwidth, `27127,"530","5300041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ7ﾁｮｳﾒ","大阪府","大阪市北区","天神橋7丁目",0,0,1,0,0,0`},
+		{"katakana without width", false, KanaKatakana, `27127,"530","5300041","オオサカフ","オオサカシキタク","テンジンバシ7チョウメ","大阪府","大阪市北区","天神橋７丁目",0,0,1,0,0,0`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultNormalizeOptions()
+			opts.Width = tt.width
+			opts.Kana = tt.kana
+
+			var w bytes.Buffer
+			if err := NormalizeWithOptions(strings.NewReader(input), &w, opts); err != nil {
+				t.Fatalf("NormalizeWithOptions() error = %v", err)
+			}
+			if got := w.String(); got != tt.want {
+				t.Errorf("NormalizeWithOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeWithOptions_romaji(t *testing.T) {
+	input, _ := japanese.ShiftJIS.NewEncoder().String(
+		`01101,"060  ","0600041","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","ｵｵﾄﾞｵﾘﾋｶﾞｼ","北海道","札幌市中央区","大通東",0,0,1,0,0,0`)
+
+	opts := DefaultNormalizeOptions()
+	opts.Kana = KanaHiragana
+	opts.Romaji = true
+	opts.RomajiStyle = RomajiStyle{LongVowel: LongVowelOmit, Case: CaseTitle}
+	want := `01101,"060","0600041","ほっかいどう","さっぽろしちゅうおうく","おおどおりひがし","北海道","札幌市中央区","大通東",0,0,1,0,0,0,"Hokkaido","Sapporoshichuoku","Odorihigashi"`
+
+	var w bytes.Buffer
+	if err := NormalizeWithOptions(strings.NewReader(input), &w, opts); err != nil {
+		t.Fatalf("NormalizeWithOptions() error = %v", err)
+	}
+	if got := w.String(); got != want {
+		t.Errorf("NormalizeWithOptions() = %v, want %v", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package gokenall
 import (
 	"encoding/csv"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -14,22 +15,80 @@ const (
 
 // JapanZipCode is a parsed line from ken_all.csv.
 type JapanZipCode struct {
-	JISCode                   string `json:"jis_code"`      // 全国地方公共団体コード（JIS X0401、X0402）………　半角数字
-	OldZipCode                string `json:"old_zip_code"`  // （旧）郵便番号（5桁）………………………………………　半角数字
-	ZipCode                   string `json:"zip_code"`      // 郵便番号（7桁）………………………………………　半角数字
-	PrefKana                  string `json:"pref_kana"`     // 都道府県名　…………　半角カタカナ（コード順に掲載）　（注1）
-	CityKana                  string `json:"city_kana"`     // 市区町村名　…………　半角カタカナ（コード順に掲載）　（注1）
-	StreetKana                string `json:"street_kana"`   // 町域名　………………　半角カタカナ（五十音順に掲載）　（注1）
-	Pref                      string `json:"pref"`          // 都道府県名　…………　漢字（コード順に掲載）　（注1,2）
-	City                      string `json:"city"`          // 市区町村名　…………　漢字（コード順に掲載）　（注1,2）
-	Street                    string `json:"street"`        // 町域名　………………　漢字（五十音順に掲載）　（注1,2）
-	StreetDuplicateZipCodeFlg string `json:"-"`             // 一町域が二以上の郵便番号で表される場合の表示　（注3）　（「1」は該当、「0」は該当せず）
-	NumberedSmallStreetFlg    string `json:"-"`             // 小字毎に番地が起番されている町域の表示　（注4）　（「1」は該当、「0」は該当せず）
-	NumberedStreetFlg         string `json:"-"`             // 丁目を有する町域の場合の表示　（「1」は該当、「0」は該当せず）
-	ZipCodeDuplicateStreetFlg string `json:"-"`             // 一つの郵便番号で二以上の町域を表す場合の表示　（注5）　（「1」は該当、「0」は該当せず）
-	UpdateFlg                 string `json:"update_flg"`    // 更新の表示（注6）（「0」は変更なし、「1」は変更あり、「2」廃止（廃止データのみ使用））
-	UpdateReason              string `json:"update_reason"` // 変更理由　（「0」は変更なし、「1」市政・区政・町政・分区・政令指定都市施行、「2」住居表示の実施、「3」区画整理、「4」郵便区調整等、「5」訂正、「6」廃止（廃止データのみ使用））
-	PrefCode                  string `json:"pref_code"`     // <ken_allにはない追加項目> 都道府県コード(JIS X0401)
+	JISCode                   string         `json:"jis_code"`                     // 全国地方公共団体コード（JIS X0401、X0402）………　半角数字
+	OldZipCode                string         `json:"old_zip_code"`                 // （旧）郵便番号（5桁）………………………………………　半角数字
+	ZipCode                   string         `json:"zip_code"`                     // 郵便番号（7桁）………………………………………　半角数字
+	PrefKana                  string         `json:"pref_kana"`                    // 都道府県名　…………　半角カタカナ（コード順に掲載）　（注1）
+	CityKana                  string         `json:"city_kana"`                    // 市区町村名　…………　半角カタカナ（コード順に掲載）　（注1）
+	StreetKana                string         `json:"street_kana"`                  // 町域名　………………　半角カタカナ（五十音順に掲載）　（注1）
+	Pref                      string         `json:"pref"`                         // 都道府県名　…………　漢字（コード順に掲載）　（注1,2）
+	City                      string         `json:"city"`                         // 市区町村名　…………　漢字（コード順に掲載）　（注1,2）
+	Street                    string         `json:"street"`                       // 町域名　………………　漢字（五十音順に掲載）　（注1,2）
+	StreetDuplicateZipCodeFlg string         `json:"-"`                            // 一町域が二以上の郵便番号で表される場合の表示　（注3）　（「1」は該当、「0」は該当せず）
+	NumberedSmallStreetFlg    string         `json:"-"`                            // 小字毎に番地が起番されている町域の表示　（注4）　（「1」は該当、「0」は該当せず）
+	NumberedStreetFlg         string         `json:"-"`                            // 丁目を有する町域の場合の表示　（「1」は該当、「0」は該当せず）
+	ZipCodeDuplicateStreetFlg string         `json:"-"`                            // 一つの郵便番号で二以上の町域を表す場合の表示　（注5）　（「1」は該当、「0」は該当せず）
+	UpdateFlg                 string         `json:"update_flg"`                   // 更新の表示（注6）（「0」は変更なし、「1」は変更あり、「2」廃止（廃止データのみ使用））
+	UpdateReason              string         `json:"update_reason"`                // 変更理由　（「0」は変更なし、「1」市政・区政・町政・分区・政令指定都市施行、「2」住居表示の実施、「3」区画整理、「4」郵便区調整等、「5」訂正、「6」廃止（廃止データのみ使用））
+	PrefCode                  string         `json:"pref_code"`                    // <ken_allにはない追加項目> 都道府県コード(JIS X0401)
+	StreetNote                string         `json:"street_note,omitempty"`        // <ken_allにはない追加項目> 町域名から除去した注記　…………　漢字
+	StreetNoteKana            string         `json:"street_note_kana,omitempty"`   // <ken_allにはない追加項目> 町域名から除去した注記　…………　半角カタカナ
+	StreetNoteType            StreetNoteType `json:"street_note_type,omitempty"`   // <ken_allにはない追加項目> 町域名から除去した注記の種類
+	Building                  string         `json:"building,omitempty"`           // <ken_allにはない追加項目> 高層ビル名　…………　漢字
+	BuildingKana              string         `json:"building_kana,omitempty"`      // <ken_allにはない追加項目> 高層ビル名　…………　半角カタカナ
+	Floor                     string         `json:"floor,omitempty"`              // <ken_allにはない追加項目> 高層ビルの階　…………　半角数字（「地階・階層不明」は FloorUnknown）
+	Trace                     *Trace         `json:"trace,omitempty"`              // <ken_allにはない追加項目> 加工の経緯
+	StreetNumberFrom          int            `json:"street_number_from,omitempty"` // <ken_allにはない追加項目> 町域名の番号の範囲の開始（例: １～３丁目の 1）
+	StreetNumberTo            int            `json:"street_number_to,omitempty"`   // <ken_allにはない追加項目> 町域名の番号の範囲の終了（例: １～３丁目の 3）
+	StreetNumberUnit          string         `json:"street_number_unit,omitempty"` // <ken_allにはない追加項目> 町域名の番号の単位（丁目、番地、番、地割）
+	PrefRoma                  string         `json:"pref_roma,omitempty"`          // <ken_allにはない追加項目> 都道府県名　…………　ローマ字（都道府県名カナから変換）
+	CityRoma                  string         `json:"city_roma,omitempty"`          // <ken_allにはない追加項目> 市区町村名　…………　ローマ字（市区町村名カナから変換）
+	StreetRoma                string         `json:"street_roma,omitempty"`        // <ken_allにはない追加項目> 町域名　………………　ローマ字（町域名カナから変換）
+	StreetSearchKey           string         `json:"street_search_key,omitempty"`  // <ken_allにはない追加項目> 町域名の検索キー（SearchKey で変換）
+}
+
+// FloorUnknown is the Floor of the row for basement or unknown floor of the building.
+const FloorUnknown = "地階・階層不明"
+
+// StreetNoteType classifies the note removed from the street name at normalize.
+type StreetNoteType uint
+
+const (
+	// StreetNoteNone represents no note is removed.
+	StreetNoteNone StreetNoteType = iota
+	// StreetNoteCatchAll represents the row covers the rest of the area. (以下に掲載がない場合, その他)
+	StreetNoteCatchAll
+	// StreetNoteExclusion represents the row excludes some parts of the area. (○○を除く)
+	StreetNoteExclusion
+	// StreetNoteOther represents the note is not classified.
+	StreetNoteOther
+	// StreetNoteFloor represents the floor of the building is unknown. (地階・階層不明)
+	StreetNoteFloor
+)
+
+var streetNoteTypeNames = []string{"", "catch_all", "exclusion", "other", "floor"}
+
+func (t StreetNoteType) String() string {
+	if int(t) < len(streetNoteTypeNames) {
+		return streetNoteTypeNames[t]
+	}
+	return fmt.Sprintf("StreetNoteType(%d)", uint(t))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t StreetNoteType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *StreetNoteType) UnmarshalText(text []byte) error {
+	for i, name := range streetNoteTypeNames {
+		if name == string(text) {
+			*t = StreetNoteType(i)
+			return nil
+		}
+	}
+	return errors.Errorf("unknown street note type: %s", text)
 }
 
 func parseCSV(line string, trim bool) (*JapanZipCode, error) {
@@ -41,6 +100,25 @@ func parseCSV(line string, trim bool) (*JapanZipCode, error) {
 	return parseArray(cols, trim)
 }
 
+func parseCSVWithOption(line string, option NormalizeOption) (*JapanZipCode, error) {
+	csvReader := csv.NewReader(strings.NewReader(line))
+	cols, err := csvReader.Read()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read csv format: %s", line)
+	}
+	if len(cols) != columnCount+extraColumnCount(option) {
+		return nil, errors.New("Column count is wrong")
+	}
+	p, err := parseArray(cols[:columnCount], false)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.parseExtraArray(cols[columnCount:], option); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
 func parseArray(cols []string, trim bool) (*JapanZipCode, error) {
 	if len(cols) != columnCount {
 		return nil, errors.New("Column count is wrong")
@@ -102,6 +180,117 @@ func (p *JapanZipCode) revertArray() []string {
 	}
 }
 
+func extraColumnCount(option NormalizeOption) int {
+	count := 0
+	if option&NormalizeStreetNote != 0 {
+		count += 3
+	}
+	if option&NormalizeBuilding != 0 {
+		count += 3
+	}
+	if option&NormalizeTrace != 0 {
+		count += 3
+	}
+	if option&NormalizeRange != 0 {
+		count += 3
+	}
+	if option&NormalizeRomaji != 0 {
+		count += 3
+	}
+	if option&NormalizeSearchKey != 0 {
+		count++
+	}
+	return count
+}
+
+func (p *JapanZipCode) parseExtraArray(cols []string, option NormalizeOption) error {
+	if option&NormalizeStreetNote != 0 {
+		p.StreetNote = cols[0]
+		p.StreetNoteKana = cols[1]
+		if err := p.StreetNoteType.UnmarshalText([]byte(cols[2])); err != nil {
+			return err
+		}
+		cols = cols[3:]
+	}
+	if option&NormalizeBuilding != 0 {
+		p.Building = cols[0]
+		p.BuildingKana = cols[1]
+		p.Floor = cols[2]
+		cols = cols[3:]
+	}
+	if option&NormalizeTrace != 0 {
+		trace, err := parseTraceArray(cols[:3])
+		if err != nil {
+			return err
+		}
+		p.Trace = trace
+		cols = cols[3:]
+	}
+	if option&NormalizeRange != 0 {
+		var err error
+		if p.StreetNumberFrom, err = parseStreetNumber(cols[0]); err != nil {
+			return err
+		}
+		if p.StreetNumberTo, err = parseStreetNumber(cols[1]); err != nil {
+			return err
+		}
+		p.StreetNumberUnit = cols[2]
+		cols = cols[3:]
+	}
+	if option&NormalizeRomaji != 0 {
+		p.PrefRoma = cols[0]
+		p.CityRoma = cols[1]
+		p.StreetRoma = cols[2]
+		cols = cols[3:]
+	}
+	if option&NormalizeSearchKey != 0 {
+		p.StreetSearchKey = cols[0]
+	}
+	return nil
+}
+
+func (p *JapanZipCode) revertExtraCSV(option NormalizeOption) string {
+	var b strings.Builder
+	if option&NormalizeStreetNote != 0 {
+		fmt.Fprintf(&b, ",\"%s\",\"%s\",%s", p.StreetNote, p.StreetNoteKana, p.StreetNoteType)
+	}
+	if option&NormalizeBuilding != 0 {
+		fmt.Fprintf(&b, ",\"%s\",\"%s\",\"%s\"", p.Building, p.BuildingKana, p.Floor)
+	}
+	if option&NormalizeTrace != 0 {
+		cols := p.Trace.revertArray()
+		fmt.Fprintf(&b, ",\"%s\",\"%s\",\"%s\"", cols[0], cols[1], cols[2])
+	}
+	if option&NormalizeRange != 0 {
+		fmt.Fprintf(&b, ",%s,%s,\"%s\"", revertStreetNumber(p.StreetNumberFrom), revertStreetNumber(p.StreetNumberTo), p.StreetNumberUnit)
+	}
+	if option&NormalizeRomaji != 0 {
+		fmt.Fprintf(&b, ",\"%s\",\"%s\",\"%s\"", p.PrefRoma, p.CityRoma, p.StreetRoma)
+	}
+	if option&NormalizeSearchKey != 0 {
+		fmt.Fprintf(&b, ",\"%s\"", p.StreetSearchKey)
+	}
+	return b.String()
+}
+
+func parseStreetNumber(col string) (int, error) {
+	if col == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(col)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid street number: %s", col)
+	}
+	return i, nil
+}
+
+func revertStreetNumber(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
 func (p *JapanZipCode) isMultiLineStart() bool {
 	oi := strings.LastIndexAny(p.Street, "(（")
 	if oi < 0 {
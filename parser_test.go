@@ -304,3 +304,84 @@ func TestJapanZipCode_revertArray(t *testing.T) {
 		})
 	}
 }
+
+func TestJapanZipCode_revertExtraCSV(t *testing.T) {
+	type args struct {
+		option NormalizeOption
+	}
+	p := &JapanZipCode{
+		StreetNote:     "その他",
+		StreetNoteKana: "ｿﾉﾀ",
+		StreetNoteType: StreetNoteCatchAll,
+	}
+	tests := []struct {
+		name string
+		p    *JapanZipCode
+		args args
+		want string
+	}{
+		{"no option", p, args{NoNormalizeOption}, ``},
+		{"street note", p, args{NormalizeStreetNote}, `,"その他","ｿﾉﾀ",catch_all`},
+		{"empty street note", &JapanZipCode{}, args{NormalizeStreetNote}, `,"","",`},
+		{"street number", &JapanZipCode{StreetNumberFrom: 1, StreetNumberTo: 3, StreetNumberUnit: "丁目"}, args{NormalizeRange}, `,1,3,"丁目"`},
+		{"empty street number", &JapanZipCode{}, args{NormalizeRange}, `,,,""`},
+		{"romaji", &JapanZipCode{PrefRoma: "hokkaidō", CityRoma: "sapporoshi", StreetRoma: "ōdōri"}, args{NormalizeRomaji}, `,"hokkaidō","sapporoshi","ōdōri"`},
+		{"search key", &JapanZipCode{StreetSearchKey: "霞ケ関"}, args{NormalizeSearchKey}, `,"霞ケ関"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.revertExtraCSV(tt.args.option); got != tt.want {
+				t.Errorf("JapanZipCode.revertExtraCSV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseCSVWithOption(t *testing.T) {
+	type args struct {
+		line   string
+		option NormalizeOption
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *JapanZipCode
+		wantErr bool
+	}{
+		{"street note", args{`26103,"606  ","6060017","ｷｮｳﾄﾌ","ｷｮｳﾄｼｻｷｮｳｸ","ｲﾜｸﾗｱｸﾞﾗﾁｮｳ","京都府","京都市左京区","岩倉上蔵町",1,0,0,0,0,0,"その他","ｿﾉﾀ",catch_all`, NormalizeStreetNote}, &JapanZipCode{
+			JISCode:                   "26103",
+			OldZipCode:                "606  ",
+			ZipCode:                   "6060017",
+			PrefKana:                  "ｷｮｳﾄﾌ",
+			CityKana:                  "ｷｮｳﾄｼｻｷｮｳｸ",
+			StreetKana:                "ｲﾜｸﾗｱｸﾞﾗﾁｮｳ",
+			Pref:                      "京都府",
+			City:                      "京都市左京区",
+			Street:                    "岩倉上蔵町",
+			StreetDuplicateZipCodeFlg: "1",
+			NumberedSmallStreetFlg:    "0",
+			NumberedStreetFlg:         "0",
+			ZipCodeDuplicateStreetFlg: "0",
+			UpdateFlg:                 "0",
+			UpdateReason:              "0",
+			PrefCode:                  "26",
+			StreetNote:                "その他",
+			StreetNoteKana:            "ｿﾉﾀ",
+			StreetNoteType:            StreetNoteCatchAll,
+		}, false},
+		{"missing columns", args{`26103,"606  ","6060017","ｷｮｳﾄﾌ","ｷｮｳﾄｼｻｷｮｳｸ","ｲﾜｸﾗｱｸﾞﾗﾁｮｳ","京都府","京都市左京区","岩倉上蔵町",1,0,0,0,0,0`, NormalizeStreetNote}, nil, true},
+		{"unknown type", args{`26103,"606  ","6060017","ｷｮｳﾄﾌ","ｷｮｳﾄｼｻｷｮｳｸ","ｲﾜｸﾗｱｸﾞﾗﾁｮｳ","京都府","京都市左京区","岩倉上蔵町",1,0,0,0,0,0,"その他","ｿﾉﾀ",unknown`, NormalizeStreetNote}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCSVWithOption(tt.args.line, tt.args.option)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseCSVWithOption() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCSVWithOption() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package gokenall
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/width"
+)
+
+// ReleaseInfo is the information of the release on japanpost website.
+type ReleaseInfo struct {
+	Date     time.Time // 更新日（見つからない場合はゼロ値）
+	Archives []Archive // ページからリンクされている zip ファイル
+}
+
+// Archive is the zip file linked from japanpost website.
+type Archive struct {
+	Kind string // 種類（ken_all, utf, rome, jigyosyo, add, del, utf_add, utf_del, jigyosyo_add, jigyosyo_del。不明な場合はファイル名から拡張子を除いたもの）
+	Name string // ファイル名
+	URL  string // URL
+	Size int64  // ページに書かれたおおよそのバイト数（不明の場合は 0）
+}
+
+// Archive returns the archive of kind. If not found, returns nil.
+func (info *ReleaseInfo) Archive(kind string) *Archive {
+	for i := range info.Archives {
+		if info.Archives[i].Kind == kind {
+			return &info.Archives[i]
+		}
+	}
+	return nil
+}
+
+// FetchReleaseInfo downloads the page on japanpost website and parses it by ParseReleaseInfo.
+// If pageURL is empty, the page of ken_all is downloaded.
+func FetchReleaseInfo(pageURL string) (*ReleaseInfo, error) {
+	if pageURL == "" {
+		pageURL = kenAllSiteURL
+	}
+	resp, err := http.Get(pageURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download url: %s", pageURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to download url: %s: %s", pageURL, resp.Status)
+	}
+	return ParseReleaseInfo(resp.Body, pageURL)
+}
+
+var (
+	releaseTagPattern   = regexp.MustCompile(`(?s)<!--.*?-->|<[^>]*>`)
+	releaseDatePattern  = regexp.MustCompile(`(\d{4})\s*年\s*(\d{1,2})\s*月\s*(\d{1,2})\s*日|(\d{4})[/.-](\d{1,2})[/.-](\d{1,2})`)
+	releaseLinkPattern  = regexp.MustCompile(`(?i)href\s*=\s*["']?([^"'\s>]+\.zip)["'\s>]`)
+	releaseSizePattern  = regexp.MustCompile(`(?i)([\d,]+(?:\.\d+)?)\s*(KB|MB|GB|Kバイト|Mバイト|バイト|B)`)
+	releaseArchiveKinds = []struct {
+		pattern *regexp.Regexp
+		kind    string
+	}{
+		{regexp.MustCompile(`^utf_ken_all$`), "utf"},
+		{regexp.MustCompile(`^ken_all_rome$`), "rome"},
+		{regexp.MustCompile(`^ken_all$`), "ken_all"},
+		{regexp.MustCompile(`^jigyosyo$`), "jigyosyo"},
+		{regexp.MustCompile(`^utf_add_\d+$`), "utf_add"},
+		{regexp.MustCompile(`^utf_del_\d+$`), "utf_del"},
+		{regexp.MustCompile(`^jadd\d+$`), "jigyosyo_add"},
+		{regexp.MustCompile(`^jdel\d+$`), "jigyosyo_del"},
+		{regexp.MustCompile(`^add_\d+$`), "add"},
+		{regexp.MustCompile(`^del_\d+$`), "del"},
+	}
+)
+
+// releaseDateDistance is the distance in bytes from the date to look for `更新`.
+const releaseDateDistance = 40
+
+// The release date is marked by `更新日：` before it or `更新` after it, but not by `更新予定`.
+var (
+	releaseUpdatedBeforePattern = regexp.MustCompile(`更新日?\s*[:：]?\s*$`)
+	releaseUpdatedAfterPattern  = regexp.MustCompile(`^\s*更新(?:[^予]|$)`)
+)
+
+// ParseReleaseInfo parses the page on japanpost website.
+// The parse is tolerant of the changes of the page: the date is searched near `更新` in the text
+// written like `2019年9月30日` or `2019/09/30` with hankaku or zenkaku digits,
+// and the archives are the links to zip files with the size written after the link like `(1.6MB)`.
+// The page may be in UTF-8 or Shift_JIS. The links are resolved by pageURL.
+func ParseReleaseInfo(r io.Reader, pageURL string) (*ReleaseInfo, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read page")
+	}
+	if !utf8.Valid(b) {
+		if b, err = japanese.ShiftJIS.NewDecoder().Bytes(b); err != nil {
+			return nil, errors.Wrap(err, "failed to decode page")
+		}
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse url: %s", pageURL)
+	}
+
+	page := string(b)
+	info := &ReleaseInfo{
+		Date: parseReleaseDate(width.Fold.String(releaseTagPattern.ReplaceAllString(page, ""))),
+	}
+
+	seen := map[string]bool{}
+	links := releaseLinkPattern.FindAllStringSubmatchIndex(page, -1)
+	for i, link := range links {
+		ref, err := url.Parse(page[link[2]:link[3]])
+		if err != nil {
+			continue
+		}
+		u := base.ResolveReference(ref).String()
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+
+		// the size is written in the text between the link and the next link
+		end := len(page)
+		if i+1 < len(links) {
+			end = links[i+1][0]
+		}
+		text := width.Fold.String(releaseTagPattern.ReplaceAllString(page[link[1]:end], ""))
+
+		name := path.Base(ref.Path)
+		info.Archives = append(info.Archives, Archive{
+			Kind: archiveKind(name),
+			Name: name,
+			URL:  u,
+			Size: parseArchiveSize(text),
+		})
+	}
+
+	if info.Date.IsZero() && len(info.Archives) == 0 {
+		return nil, errors.New("not found updated date string nor archives in website")
+	}
+	return info, nil
+}
+
+// parseReleaseDate returns the first date marked as updated in text like `2019年9月30日更新` or `更新日：2019年9月30日`.
+// The date of the next update like `次回更新予定 2019年10月31日` is not the release date, so it is skipped.
+func parseReleaseDate(text string) time.Time {
+	for _, m := range releaseDatePattern.FindAllStringSubmatchIndex(text, -1) {
+		from, to := m[0]-releaseDateDistance, m[1]+releaseDateDistance
+		if from < 0 {
+			from = 0
+		}
+		if to > len(text) {
+			to = len(text)
+		}
+		if !releaseUpdatedBeforePattern.MatchString(text[from:m[0]]) && !releaseUpdatedAfterPattern.MatchString(text[m[1]:to]) {
+			continue
+		}
+
+		groups := m[2:8]
+		if groups[0] < 0 {
+			groups = m[8:14]
+		}
+		var ymd [3]int
+		for i := range ymd {
+			ymd[i], _ = strconv.Atoi(text[groups[i*2]:groups[i*2+1]])
+		}
+		t := time.Date(ymd[0], time.Month(ymd[1]), ymd[2], 0, 0, 0, 0, time.UTC)
+		if t.Month() != time.Month(ymd[1]) || t.Day() != ymd[2] {
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func archiveKind(name string) string {
+	base := strings.TrimSuffix(strings.ToLower(name), ".zip")
+	for _, k := range releaseArchiveKinds {
+		if k.pattern.MatchString(base) {
+			return k.kind
+		}
+	}
+	return base
+}
+
+// parseArchiveSize returns the first size in text like `1.6MB`, or 0 if not found.
+func parseArchiveSize(text string) int64 {
+	m := releaseSizePattern.FindStringSubmatch(text)
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.ParseFloat(strings.Replace(m[1], ",", "", -1), 64)
+	if err != nil {
+		return 0
+	}
+	switch strings.ToUpper(m[2]) {
+	case "KB", "Kバイト":
+		n *= 1 << 10
+	case "MB", "Mバイト":
+		n *= 1 << 20
+	case "GB":
+		n *= 1 << 30
+	}
+	return int64(n)
+}
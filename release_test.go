@@ -0,0 +1,76 @@
+package gokenall
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReleaseInfo(t *testing.T) {
+	tests := []struct {
+		file    string
+		pageURL string
+		want    *ReleaseInfo
+	}{
+		{
+			"testdata/kogaki-zip.html",
+			"https://www.post.japanpost.jp/zipcode/dl/kogaki-zip.html",
+			&ReleaseInfo{
+				Date: time.Date(2019, 9, 30, 0, 0, 0, 0, time.UTC),
+				Archives: []Archive{
+					{"ken_all", "ken_all.zip", "https://www.post.japanpost.jp/zipcode/dl/kogaki/zip/ken_all.zip", 1677721},
+					{"01hokkai", "01hokkai.zip", "https://www.post.japanpost.jp/zipcode/dl/kogaki/zip/01hokkai.zip", 174080},
+					{"add", "add_1909.zip", "https://www.post.japanpost.jp/zipcode/dl/kogaki/zip/add_1909.zip", 2345},
+					{"del", "del_1909.zip", "https://www.post.japanpost.jp/zipcode/dl/kogaki/zip/del_1909.zip", 1024},
+					{"rome", "ken_all_rome.zip", "https://www.post.japanpost.jp/zipcode/dl/roman/ken_all_rome.zip", 1258291},
+				},
+			},
+		},
+		{
+			"testdata/jigyosyo-sjis.html",
+			"http://www.post.japanpost.jp/zipcode/dl/jigyosyo/index-zip.html",
+			&ReleaseInfo{
+				Date: time.Date(2018, 12, 28, 0, 0, 0, 0, time.UTC),
+				Archives: []Archive{
+					{"jigyosyo", "jigyosyo.zip", "http://www.post.japanpost.jp/zipcode/dl/jigyosyo/zip/jigyosyo.zip", 729088},
+					{"jigyosyo_add", "jadd1812.zip", "http://www.post.japanpost.jp/zipcode/dl/jigyosyo/jigyosyo/zip/jadd1812.zip", 0},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f, err := os.Open(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			got, err := ParseReleaseInfo(f, tt.pageURL)
+			if err != nil {
+				t.Fatalf("ParseReleaseInfo() error = %+v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseReleaseInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReleaseInfo_error(t *testing.T) {
+	if _, err := ParseReleaseInfo(strings.NewReader("<html><body>メンテナンス中</body></html>"), kenAllSiteURL); err == nil {
+		t.Errorf("ParseReleaseInfo() error = nil, want error")
+	}
+}
+
+func TestReleaseInfo_Archive(t *testing.T) {
+	info := &ReleaseInfo{Archives: []Archive{{Kind: "ken_all", Name: "ken_all.zip"}, {Kind: "add", Name: "add_1909.zip"}}}
+	if got := info.Archive("add"); got == nil || got.Name != "add_1909.zip" {
+		t.Errorf("Archive(add) = %v", got)
+	}
+	if got := info.Archive("del"); got != nil {
+		t.Errorf("Archive(del) = %v, want nil", got)
+	}
+}
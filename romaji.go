@@ -0,0 +1,242 @@
+package gokenall
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// RomajiStyle is the style of romanization by KanaToRomaji.
+// The zero value is the modified Hepburn with macrons in lower case like `tōkyōto`.
+type RomajiStyle struct {
+	LongVowel LongVowel // 長音の表記
+	Case      Case      // 大文字・小文字
+}
+
+// LongVowel is the notation of the long vowels in romaji.
+type LongVowel uint
+
+const (
+	// LongVowelMacron writes the long vowels with macrons like `tōkyō`.
+	LongVowelMacron LongVowel = iota
+	// LongVowelCircumflex writes the long vowels with circumflexes like `tôkyô`.
+	LongVowelCircumflex
+	// LongVowelOmit omits the long vowels like `tokyo`.
+	LongVowelOmit
+	// LongVowelDouble writes the long vowels as the kana like `toukyou`.
+	LongVowelDouble
+)
+
+// Case is the letter case of romaji.
+type Case uint
+
+const (
+	// CaseLower is lower case like `sapporoshi`.
+	CaseLower Case = iota
+	// CaseTitle is lower case with the first letter of each word in upper case like `Sapporoshi`.
+	CaseTitle
+	// CaseUpper is upper case like `SAPPOROSHI`.
+	CaseUpper
+)
+
+var longVowelNames = []string{"macron", "circumflex", "omit", "double"}
+
+func (v LongVowel) String() string {
+	if int(v) < len(longVowelNames) {
+		return longVowelNames[v]
+	}
+	return fmt.Sprintf("LongVowel(%d)", uint(v))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *LongVowel) UnmarshalText(text []byte) error {
+	for i, name := range longVowelNames {
+		if name == string(text) {
+			*v = LongVowel(i)
+			return nil
+		}
+	}
+	return errors.Errorf("unknown long vowel notation: %s", text)
+}
+
+var caseNames = []string{"lower", "title", "upper"}
+
+func (c Case) String() string {
+	if int(c) < len(caseNames) {
+		return caseNames[c]
+	}
+	return fmt.Sprintf("Case(%d)", uint(c))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *Case) UnmarshalText(text []byte) error {
+	for i, name := range caseNames {
+		if name == string(text) {
+			*c = Case(i)
+			return nil
+		}
+	}
+	return errors.Errorf("unknown letter case: %s", text)
+}
+
+var romajiMap = map[string]string{
+	"ア": "a", "イ": "i", "ウ": "u", "エ": "e", "オ": "o",
+	"カ": "ka", "キ": "ki", "ク": "ku", "ケ": "ke", "コ": "ko",
+	"ガ": "ga", "ギ": "gi", "グ": "gu", "ゲ": "ge", "ゴ": "go",
+	"サ": "sa", "シ": "shi", "ス": "su", "セ": "se", "ソ": "so",
+	"ザ": "za", "ジ": "ji", "ズ": "zu", "ゼ": "ze", "ゾ": "zo",
+	"タ": "ta", "チ": "chi", "ツ": "tsu", "テ": "te", "ト": "to",
+	"ダ": "da", "ヂ": "ji", "ヅ": "zu", "デ": "de", "ド": "do",
+	"ナ": "na", "ニ": "ni", "ヌ": "nu", "ネ": "ne", "ノ": "no",
+	"ハ": "ha", "ヒ": "hi", "フ": "fu", "ヘ": "he", "ホ": "ho",
+	"バ": "ba", "ビ": "bi", "ブ": "bu", "ベ": "be", "ボ": "bo",
+	"パ": "pa", "ピ": "pi", "プ": "pu", "ペ": "pe", "ポ": "po",
+	"マ": "ma", "ミ": "mi", "ム": "mu", "メ": "me", "モ": "mo",
+	"ヤ": "ya", "ユ": "yu", "ヨ": "yo",
+	"ラ": "ra", "リ": "ri", "ル": "ru", "レ": "re", "ロ": "ro",
+	"ワ": "wa", "ヰ": "i", "ヱ": "e", "ヲ": "o",
+	"ヴ": "vu",
+	"ァ": "a", "ィ": "i", "ゥ": "u", "ェ": "e", "ォ": "o",
+	"ャ": "ya", "ュ": "yu", "ョ": "yo", "ヮ": "wa", "ヵ": "ka", "ヶ": "ke",
+
+	"キャ": "kya", "キュ": "kyu", "キョ": "kyo",
+	"ギャ": "gya", "ギュ": "gyu", "ギョ": "gyo",
+	"シャ": "sha", "シュ": "shu", "ショ": "sho", "シェ": "she",
+	"ジャ": "ja", "ジュ": "ju", "ジョ": "jo", "ジェ": "je",
+	"チャ": "cha", "チュ": "chu", "チョ": "cho", "チェ": "che",
+	"ヂャ": "ja", "ヂュ": "ju", "ヂョ": "jo",
+	"ニャ": "nya", "ニュ": "nyu", "ニョ": "nyo",
+	"ヒャ": "hya", "ヒュ": "hyu", "ヒョ": "hyo",
+	"ビャ": "bya", "ビュ": "byu", "ビョ": "byo",
+	"ピャ": "pya", "ピュ": "pyu", "ピョ": "pyo",
+	"ミャ": "mya", "ミュ": "myu", "ミョ": "myo",
+	"リャ": "rya", "リュ": "ryu", "リョ": "ryo",
+	"ファ": "fa", "フィ": "fi", "フェ": "fe", "フォ": "fo", "フュ": "fyu",
+	"ティ": "ti", "ディ": "di", "トゥ": "tu", "ドゥ": "du", "テュ": "tyu", "デュ": "dyu",
+	"ウィ": "wi", "ウェ": "we", "ウォ": "wo", "イェ": "ye",
+	"ヴァ": "va", "ヴィ": "vi", "ヴェ": "ve", "ヴォ": "vo",
+	"ツァ": "tsa", "ツィ": "tsi", "ツェ": "tse", "ツォ": "tso",
+}
+
+var (
+	macronMap     = map[byte]string{'a': "ā", 'i': "ī", 'u': "ū", 'e': "ē", 'o': "ō"}
+	circumflexMap = map[byte]string{'a': "â", 'i': "î", 'u': "û", 'e': "ê", 'o': "ô"}
+)
+
+// KanaToRomaji converts the kana text to romaji by the modified Hepburn.
+// The kana may be half-width katakana like ken_all.csv, full-width katakana or hiragana.
+// `ou`, `oo`, `uu` and `ー` are treated as the long vowels, `ッ` doubles the next consonant,
+// and `ン` before a vowel or `y` is written as `n'`.
+func KanaToRomaji(kana string, style RomajiStyle) string {
+	runes := []rune(KanaKatakana.convert(kana))
+
+	var b strings.Builder
+	sokuon := false
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch r {
+		case 'ッ':
+			sokuon = true
+			continue
+		case 'ー':
+			writeLongVowel(&b, lastVowel(b.String()), style.LongVowel)
+			continue
+		case 'ン':
+			b.WriteString("n")
+			if next := nextRomaji(runes[i+1:]); next != "" && strings.ContainsAny(next[:1], "aiueoy") {
+				b.WriteString("'")
+			}
+			continue
+		case '、':
+			r = ','
+		case '・':
+			r = ' '
+		}
+
+		syllable, size := "", 0
+		if i+1 < len(runes) {
+			syllable, size = romajiMap[string(runes[i:i+2])], 2
+		}
+		if syllable == "" {
+			syllable, size = romajiMap[string(r)], 1
+		}
+		if syllable == "" {
+			sokuon = false
+			b.WriteRune(r)
+			continue
+		}
+		i += size - 1
+
+		if sokuon {
+			sokuon = false
+			if strings.HasPrefix(syllable, "ch") {
+				b.WriteString("t")
+			} else if !strings.ContainsAny(syllable[:1], "aiueo") {
+				b.WriteString(syllable[:1])
+			}
+		} else if vowel := lastVowel(b.String()); style.LongVowel != LongVowelDouble && ((syllable == "u" && (vowel == 'o' || vowel == 'u')) || (syllable == "o" && vowel == 'o')) {
+			writeLongVowel(&b, vowel, style.LongVowel)
+			continue
+		}
+		b.WriteString(syllable)
+	}
+
+	return style.Case.apply(b.String())
+}
+
+// lastVowel returns the vowel at the end of s, or 0 if s does not end with a plain vowel.
+func lastVowel(s string) byte {
+	if s == "" || !strings.ContainsAny(s[len(s)-1:], "aiueo") {
+		return 0
+	}
+	return s[len(s)-1]
+}
+
+// writeLongVowel makes the vowel at the end of b long.
+func writeLongVowel(b *strings.Builder, vowel byte, notation LongVowel) {
+	if vowel == 0 {
+		return
+	}
+	s := b.String()
+	switch notation {
+	case LongVowelMacron:
+		b.Reset()
+		b.WriteString(s[:len(s)-1] + macronMap[vowel])
+	case LongVowelCircumflex:
+		b.Reset()
+		b.WriteString(s[:len(s)-1] + circumflexMap[vowel])
+	case LongVowelDouble:
+		b.WriteByte(vowel)
+	}
+}
+
+func nextRomaji(runes []rune) string {
+	if len(runes) == 0 {
+		return ""
+	}
+	if len(runes) > 1 {
+		if s, ok := romajiMap[string(runes[:2])]; ok {
+			return s
+		}
+	}
+	return romajiMap[string(runes[0])]
+}
+
+func (c Case) apply(s string) string {
+	switch c {
+	case CaseTitle:
+		runes := []rune(s)
+		for i, r := range runes {
+			if i == 0 || !unicode.IsLetter(runes[i-1]) && runes[i-1] != '\'' {
+				runes[i] = unicode.ToUpper(r)
+			}
+		}
+		return string(runes)
+	case CaseUpper:
+		return strings.ToUpper(s)
+	}
+	return s
+}
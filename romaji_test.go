@@ -0,0 +1,58 @@
+package gokenall
+
+import "testing"
+
+func TestKanaToRomaji(t *testing.T) {
+	type args struct {
+		kana  string
+		style RomajiStyle
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"macron", args{"ﾄｳｷｮｳﾄ", RomajiStyle{}}, "tōkyōto"},
+		{"circumflex", args{"ﾄｳｷｮｳﾄ", RomajiStyle{LongVowel: LongVowelCircumflex}}, "tôkyôto"},
+		{"omit", args{"ﾄｳｷｮｳﾄ", RomajiStyle{LongVowel: LongVowelOmit}}, "tokyoto"},
+		{"double", args{"ﾄｳｷｮｳﾄ", RomajiStyle{LongVowel: LongVowelDouble}}, "toukyouto"},
+		{"oo", args{"ｵｵｻｶﾌ", RomajiStyle{}}, "ōsakafu"},
+		{"uu", args{"ﾁｭｳｵｳｸ", RomajiStyle{}}, "chūōku"},
+		{"sokuon", args{"ﾎｯｶｲﾄﾞｳ", RomajiStyle{}}, "hokkaidō"},
+		{"sokuon before ch", args{"ﾏｯﾁｬ", RomajiStyle{}}, "matcha"},
+		{"n before vowel", args{"ｼﾝｵｵｸﾎﾞ", RomajiStyle{}}, "shin'ōkubo"},
+		{"n before y", args{"ｷﾝﾔ", RomajiStyle{}}, "kin'ya"},
+		{"n before consonant", args{"ｼﾝｼﾞｭｸｸ", RomajiStyle{}}, "shinjukuku"},
+		{"chouon", args{"ｾﾝﾀｰﾋﾞﾙ", RomajiStyle{}}, "sentābiru"},
+		{"chouon omit", args{"ｾﾝﾀｰﾋﾞﾙ", RomajiStyle{LongVowel: LongVowelOmit}}, "sentabiru"},
+		{"chouon double", args{"ｾﾝﾀｰﾋﾞﾙ", RomajiStyle{LongVowel: LongVowelDouble}}, "sentaabiru"},
+		{"loanword", args{"ﾌｧｰﾑ", RomajiStyle{}}, "fāmu"},
+		{"digits", args{"ﾃﾝｼﾞﾝﾊﾞｼ7ﾁｮｳﾒ", RomajiStyle{}}, "tenjinbashi7chōme"},
+		{"hiragana", args{"さっぽろし", RomajiStyle{}}, "sapporoshi"},
+		{"title", args{"ｻｯﾎﾟﾛｼ ﾁｭｳｵｳｸ", RomajiStyle{Case: CaseTitle}}, "Sapporoshi Chūōku"},
+		{"upper", args{"ﾄｳｷｮｳﾄ", RomajiStyle{LongVowel: LongVowelOmit, Case: CaseUpper}}, "TOKYOTO"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KanaToRomaji(tt.args.kana, tt.args.style); got != tt.want {
+				t.Errorf("KanaToRomaji() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLongVowel_UnmarshalText(t *testing.T) {
+	for _, v := range []LongVowel{LongVowelMacron, LongVowelCircumflex, LongVowelOmit, LongVowelDouble} {
+		var got LongVowel
+		if err := got.UnmarshalText([]byte(v.String())); err != nil || got != v {
+			t.Errorf("LongVowel.UnmarshalText() = %v, %v, want %v", got, err, v)
+		}
+	}
+	var c Case
+	if err := c.UnmarshalText([]byte("title")); err != nil || c != CaseTitle {
+		t.Errorf("Case.UnmarshalText() = %v, %v, want %v", c, err, CaseTitle)
+	}
+	if err := c.UnmarshalText([]byte("camel")); err == nil {
+		t.Errorf("Case.UnmarshalText() error = nil, want error for unknown case")
+	}
+}
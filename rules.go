@@ -0,0 +1,518 @@
+package gokenall
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/width"
+)
+
+// StreetRule normalizes the street name of a row at Normalize.
+// Normalize runs the rules in order and the first rule which handles the row wins.
+// If no rule handles the row, the parenthetical is just removed from the street name.
+type StreetRule interface {
+	// Name returns the name to enable, disable or reorder the rule.
+	Name() string
+	// Apply returns the normalized rows and true if the rule handles the street name of p.
+	// p must not be modified. Use parts.Row to make a new row.
+	// The error stops Normalize, so return false instead if the street name is just not for the rule.
+	Apply(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error)
+}
+
+// StreetParts is the street name split into the parenthetical and the rest.
+// If the street name has no parenthetical, Inner and InnerKana are empty.
+type StreetParts struct {
+	Street     string // 括弧を除いた町域名　………………　漢字
+	StreetKana string // 括弧を除いた町域名　………………　半角カタカナ
+	Inner      string // 括弧内の文字　………………　漢字
+	InnerKana  string // 括弧内の文字　………………　半角カタカナ
+
+	rangeLimit int  // 範囲を分割する最大の行数
+	keepRange  bool // 範囲を分割せずに1行で残すかどうか
+}
+
+// Row makes a copy of p whose street name is parts.Street followed by street.
+func (parts StreetParts) Row(p *JapanZipCode, street, streetKana string) *JapanZipCode {
+	row := *p
+	row.Street = parts.Street + street
+	row.StreetKana = parts.StreetKana + streetKana
+	return &row
+}
+
+func splitStreet(p *JapanZipCode) StreetParts {
+	parts := StreetParts{Street: p.Street, StreetKana: p.StreetKana}
+	matches := streetBlessReg.FindStringSubmatch(p.Street)
+	if matches == nil {
+		return parts
+	}
+	parts.Inner = matches[1]
+	parts.Street = streetBlessReg.ReplaceAllString(p.Street, "")
+	if matchesKana := streetKanaBlessReg.FindStringSubmatch(p.StreetKana); len(matchesKana) > 1 {
+		parts.InnerKana = matchesKana[1]
+	}
+	parts.StreetKana = streetKanaBlessReg.ReplaceAllString(p.StreetKana, "")
+	return parts
+}
+
+type streetRuleFunc struct {
+	name  string
+	apply func(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error)
+}
+
+// NewStreetRule creates StreetRule from the name and the function.
+func NewStreetRule(name string, apply func(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error)) StreetRule {
+	return &streetRuleFunc{name, apply}
+}
+
+func (rule *streetRuleFunc) Name() string {
+	return rule.name
+}
+
+func (rule *streetRuleFunc) Apply(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error) {
+	return rule.apply(p, parts)
+}
+
+var (
+	streetRulesMu         sync.RWMutex
+	registeredStreetRules = []StreetRule{
+		NewStreetRule("clear", applyClearRule),
+		NewStreetRule("note", applyNoteRule),
+		NewStreetRule("floor", applyFloorRule),
+		NewStreetRule("range", applyRangeRule),
+		NewStreetRule("number-list", applyNumberListRule),
+		NewStreetRule("chiwari", applyChiwariRule),
+		NewStreetRule("name-list", applyNameListRule),
+		NewStreetRule("mixed-list", applyMixedListRule),
+	}
+)
+
+// DefaultRangeLimit is the default number of rows a range like `（１～３丁目）` is split into at most.
+// The street name of the longer range is not split, and the parenthetical is just removed.
+const DefaultRangeLimit = 99
+
+// RegisterStreetRule adds the rule after the registered rules.
+// The rule is enabled unless the rules are selected by NormalizeOptions.Rules.
+func RegisterStreetRule(rule StreetRule) error {
+	streetRulesMu.Lock()
+	defer streetRulesMu.Unlock()
+	for _, r := range registeredStreetRules {
+		if r.Name() == rule.Name() {
+			return errors.Errorf("street rule is already registered: %s", rule.Name())
+		}
+	}
+	registeredStreetRules = append(registeredStreetRules, rule)
+	return nil
+}
+
+// StreetRuleNames returns the names of the registered rules in the default order.
+func StreetRuleNames() []string {
+	streetRulesMu.RLock()
+	defer streetRulesMu.RUnlock()
+	names := make([]string, len(registeredStreetRules))
+	for i, rule := range registeredStreetRules {
+		names[i] = rule.Name()
+	}
+	return names
+}
+
+func lookupStreetRules(names []string) ([]StreetRule, error) {
+	streetRulesMu.RLock()
+	defer streetRulesMu.RUnlock()
+	if len(names) == 0 {
+		return nil, nil
+	}
+	rules := make([]StreetRule, 0, len(names))
+	for _, name := range names {
+		var found StreetRule
+		for _, rule := range registeredStreetRules {
+			if rule.Name() == name {
+				found = rule
+				break
+			}
+		}
+		if found == nil {
+			return nil, errors.Errorf("unknown street rule: %s", name)
+		}
+		rules = append(rules, found)
+	}
+	return rules, nil
+}
+
+func defaultStreetRules() []StreetRule {
+	streetRulesMu.RLock()
+	defer streetRulesMu.RUnlock()
+	return append([]StreetRule{}, registeredStreetRules...)
+}
+
+const (
+	clearStreetRegExp       = `(^以下に掲載がない場合$|の次に番地がくる場合$|.+一円$)`
+	streetBlessRegExp       = `（([^）]+)）$`
+	streetKanaBlessRegExp   = `\(([^\)]+)\)$`
+	streetInnerBlessRegExp1 = `^(その他|地階・階層不明|.*を除く)$`
+	streetInnerBlessRegExp2 = `^([０１２３４５６７８９]+)階$`
+	streetInnerBlessRegExp3 = `^([` + numeralChars + `]+)～([` + numeralChars + `]+)(丁目|番地|番)$`
+	streetInnerBlessRegExp4 = `^([` + numeralChars + `、]+)(丁目|番地|番)$`
+	streetInnerBlessRegExp5 = `^[^「」～－０１２３４５６７８９]+$`
+	streetInnerBlessRegExp6 = `^(第)?([` + numeralChars + `]+)地割(?:「([^」]*を除く)」)?(?:～(第)?([` + numeralChars + `]+)地割(?:「([^」]*を除く)」)?)?$`
+	streetKanaNoteRegExp    = `<([^>]*)>`
+	streetListItemRegExp    = `^(第)?([` + numeralChars + `]+)(丁目|番地|番|地割)?(?:～(第)?([` + numeralChars + `]+)(丁目|番地|番|地割)?)?$`
+)
+
+var (
+	clearStreetReg       = regexp.MustCompile(clearStreetRegExp)
+	streetBlessReg       = regexp.MustCompile(streetBlessRegExp)
+	streetKanaBlessReg   = regexp.MustCompile(streetKanaBlessRegExp)
+	streetInnerBlessReg1 = regexp.MustCompile(streetInnerBlessRegExp1)
+	streetInnerBlessReg2 = regexp.MustCompile(streetInnerBlessRegExp2)
+	streetInnerBlessReg3 = regexp.MustCompile(streetInnerBlessRegExp3)
+	streetInnerBlessReg4 = regexp.MustCompile(streetInnerBlessRegExp4)
+	streetInnerBlessReg5 = regexp.MustCompile(streetInnerBlessRegExp5)
+	streetInnerBlessReg6 = regexp.MustCompile(streetInnerBlessRegExp6)
+	streetKanaNoteReg    = regexp.MustCompile(streetKanaNoteRegExp)
+	streetListItemReg    = regexp.MustCompile(streetListItemRegExp)
+)
+
+var zen2hanMap = map[string]string{
+	"丁目": "ﾁｮｳﾒ",
+	"番地": "ﾊﾞﾝﾁ",
+	"番":  "ﾊﾞﾝ",
+	"地割": "ﾁﾜﾘ",
+	"第":  "ﾀﾞｲ",
+}
+
+// applyClearRule removes `以下に掲載がない場合`, `～の次に番地がくる場合` and `～一円`.
+func applyClearRule(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error) {
+	matches := clearStreetReg.FindStringSubmatch(p.Street)
+	if matches == nil {
+		return nil, false, nil
+	}
+	row := *p
+	row.StreetNote = p.Street
+	row.StreetNoteKana = p.StreetKana
+	row.StreetNoteType = StreetNoteOther
+	if matches[1] == "以下に掲載がない場合" {
+		row.StreetNoteType = StreetNoteCatchAll
+	}
+	row.Street = ""
+	row.StreetKana = ""
+	return []*JapanZipCode{&row}, true, nil
+}
+
+// applyNoteRule removes `（その他）`, `（地階・階層不明）` and `（～を除く）`.
+func applyNoteRule(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error) {
+	matches := streetInnerBlessReg1.FindStringSubmatch(parts.Inner)
+	if matches == nil {
+		return nil, false, nil
+	}
+	row := parts.Row(p, "", "")
+	row.StreetNote = parts.Inner
+	row.StreetNoteKana = parts.InnerKana
+	switch matches[1] {
+	case "その他":
+		row.StreetNoteType = StreetNoteCatchAll
+	case "地階・階層不明":
+		row.StreetNoteType = StreetNoteFloor
+		row.Building = parts.Street
+		row.BuildingKana = parts.StreetKana
+		row.Floor = FloorUnknown
+	default:
+		row.StreetNoteType = StreetNoteExclusion
+	}
+	return []*JapanZipCode{row}, true, nil
+}
+
+// applyFloorRule keeps `（○階）`.
+func applyFloorRule(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error) {
+	matches := streetInnerBlessReg2.FindStringSubmatch(parts.Inner)
+	if matches == nil {
+		return nil, false, nil
+	}
+	floor := width.Narrow.String(matches[1])
+	row := parts.Row(p, matches[1]+"階", floor+"ｶｲ")
+	row.Building = parts.Street
+	row.BuildingKana = parts.StreetKana
+	row.Floor = floor
+	return []*JapanZipCode{row}, true, nil
+}
+
+// applyRangeRule splits `（○～○丁目）`, `（○～○番地）` and `（○～○番）`.
+func applyRangeRule(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error) {
+	matches := streetInnerBlessReg3.FindStringSubmatch(parts.Inner)
+	if matches == nil {
+		return nil, false, nil
+	}
+	start, err := numeral2Int(matches[1])
+	if err != nil {
+		return nil, false, err
+	}
+	end, err := numeral2Int(matches[2])
+	if err != nil {
+		return nil, false, err
+	}
+	if start > end {
+		return nil, false, nil
+	}
+	if parts.keepRange {
+		street, streetKana := rangeStreet("", start, end, matches[1], matches[3])
+		return []*JapanZipCode{setStreetNumber(parts.Row(p, street, streetKana), start, end, matches[3])}, true, nil
+	}
+	if (end - start + 1) > parts.rangeLimit {
+		return nil, false, nil
+	}
+	rows := []*JapanZipCode{}
+	for i := start; i <= end; i++ {
+		rows = append(rows, parts.numberRow(p, "", i, matches[1], matches[3]))
+	}
+	return rows, true, nil
+}
+
+// applyNumberListRule splits `（○、○、○丁目）`, `（○、○、○番地）` and `（○、○、○番）`.
+func applyNumberListRule(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error) {
+	matches := streetInnerBlessReg4.FindStringSubmatch(parts.Inner)
+	if matches == nil {
+		return nil, false, nil
+	}
+	rows := []*JapanZipCode{}
+	for _, s := range strings.Split(matches[1], "、") {
+		if s == "" {
+			return nil, false, nil
+		}
+		i, err := numeral2Int(s)
+		if err != nil {
+			return nil, false, err
+		}
+		row := parts.Row(p, s+matches[2], fmt.Sprintf("%d%s", i, zen2hanMap[matches[2]]))
+		if parts.keepRange {
+			setStreetNumber(row, i, i, matches[2])
+		}
+		rows = append(rows, row)
+	}
+	return rows, true, nil
+}
+
+// applyChiwariRule splits `（第○地割～第○地割）` and `（○地割）`.
+// The exclusion like `第○地割「○番地を除く」` is kept in the street note of the row.
+func applyChiwariRule(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error) {
+	matches := streetInnerBlessReg6.FindStringSubmatch(parts.Inner)
+	if matches == nil {
+		return nil, false, nil
+	}
+	start, err := numeral2Int(matches[2])
+	if err != nil {
+		return nil, false, err
+	}
+	end := start
+	if matches[5] != "" {
+		if end, err = numeral2Int(matches[5]); err != nil {
+			return nil, false, err
+		}
+	}
+	if start > end {
+		return nil, false, nil
+	}
+
+	// the kana of the notes are quoted by `<>` in the same order
+	notes := map[int]string{}
+	notesKana := map[int]string{}
+	kana := streetKanaNoteReg.FindAllStringSubmatch(parts.InnerKana, -1)
+	for _, note := range []struct {
+		n    int
+		text string
+	}{{start, matches[3]}, {end, matches[6]}} {
+		if note.text == "" {
+			continue
+		}
+		if len(kana) == 0 {
+			return nil, false, nil
+		}
+		notes[note.n] = note.text
+		notesKana[note.n] = kana[0][1]
+		kana = kana[1:]
+	}
+	if len(kana) != 0 {
+		return nil, false, nil
+	}
+	withNote := func(row *JapanZipCode, n int) *JapanZipCode {
+		if note, ok := notes[n]; ok {
+			row.StreetNote = note
+			row.StreetNoteKana = notesKana[n]
+			row.StreetNoteType = StreetNoteExclusion
+		}
+		return row
+	}
+
+	if parts.keepRange && start < end {
+		street, streetKana := rangeStreet(matches[1], start, end, matches[2], "地割")
+		row := setStreetNumber(parts.Row(p, street, streetKana), start, end, "地割")
+		// the notes of both ends are kept with the chiwari they belong to like `第１地割「～を除く」`
+		keptNotes, keptNotesKana := []string{}, []string{}
+		for _, n := range []int{start, end} {
+			if note, ok := notes[n]; ok {
+				keptNotes = append(keptNotes, matches[1]+int2Numeral(n, matches[2])+"地割「"+note+"」")
+				keptNotesKana = append(keptNotesKana, fmt.Sprintf("%s%dﾁﾜﾘ<%s>", zen2hanMap[matches[1]], n, notesKana[n]))
+			}
+		}
+		if len(keptNotes) > 0 {
+			row.StreetNote = strings.Join(keptNotes, "、")
+			row.StreetNoteKana = strings.Join(keptNotesKana, "､")
+			row.StreetNoteType = StreetNoteExclusion
+		}
+		return []*JapanZipCode{row}, true, nil
+	}
+	if (end - start + 1) > parts.rangeLimit {
+		return nil, false, nil
+	}
+	rows := []*JapanZipCode{}
+	for i := start; i <= end; i++ {
+		rows = append(rows, withNote(parts.numberRow(p, matches[1], i, matches[2], "地割"), i))
+	}
+	return rows, true, nil
+}
+
+// applyNameListRule splits `（地名、地名、地名）`.
+func applyNameListRule(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error) {
+	if parts.Inner == "" || streetInnerBlessReg5.FindString(parts.Inner) == "" {
+		return nil, false, nil
+	}
+	splits := strings.Split(parts.Inner, "、")
+	splitsKana := strings.Split(parts.InnerKana, "､")
+	if len(splits) != len(splitsKana) {
+		return nil, false, nil
+	}
+	rows := []*JapanZipCode{}
+	for i := range splits {
+		rows = append(rows, parts.Row(p, splits[i], splitsKana[i]))
+	}
+	return rows, true, nil
+}
+
+// applyMixedListRule splits lists whose items are ranges, numbers or quoted names like `（１～３丁目、５丁目）`.
+func applyMixedListRule(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error) {
+	if parts.Inner == "" {
+		return nil, false, nil
+	}
+	items, ok, err := parseStreetList(parts.Inner, parts.InnerKana, parts.rangeLimit, parts.keepRange)
+	if !ok || err != nil {
+		return nil, false, err
+	}
+	rows := []*JapanZipCode{}
+	for _, item := range items {
+		row := parts.Row(p, item.street, item.streetKana)
+		if parts.keepRange && item.unit != "" {
+			setStreetNumber(row, item.from, item.to, item.unit)
+		}
+		rows = append(rows, row)
+	}
+	return rows, true, nil
+}
+
+// streetListItem is one town area expanded from a parenthesized list.
+type streetListItem struct {
+	street     string
+	streetKana string
+	from       int
+	to         int
+	unit       string
+}
+
+// parseStreetList parses comma separated lists like `１～３丁目、５丁目` or `「東」、「西」`.
+// Each item may be a range, a single number or a quoted name.
+// A unit omitted in an item is taken from the following item like `７、８丁目`.
+// The ranges are split into each number up to limit rows in total, or kept in one item if keepRange is true.
+func parseStreetList(innerBless, innerBlessKana string, limit int, keepRange bool) ([]streetListItem, bool, error) {
+	splits := splitOutside(innerBless, "、", "「", "」")
+	splitsKana := splitOutside(innerBlessKana, "､", "<", ">")
+
+	items := []streetListItem{}
+	unit := ""
+	for i := len(splits) - 1; i >= 0; i-- {
+		if strings.HasPrefix(splits[i], "「") && strings.HasSuffix(splits[i], "」") {
+			name := strings.TrimSuffix(strings.TrimPrefix(splits[i], "「"), "」")
+			if name == "" || strings.ContainsAny(name, "「」") || len(splits) != len(splitsKana) {
+				return nil, false, nil
+			}
+			nameKana := strings.TrimSuffix(strings.TrimPrefix(splitsKana[i], "<"), ">")
+			items = append([]streetListItem{{street: name, streetKana: nameKana}}, items...)
+			continue
+		}
+
+		matches := streetListItemReg.FindStringSubmatch(splits[i])
+		if matches == nil {
+			return nil, false, nil
+		}
+		if matches[6] != "" {
+			unit = matches[6]
+		} else if matches[3] != "" && matches[5] == "" {
+			unit = matches[3]
+		}
+		if unit == "" {
+			return nil, false, nil
+		}
+		if matches[3] != "" && matches[5] != "" && matches[3] != unit {
+			return nil, false, nil
+		}
+
+		start, err := numeral2Int(matches[2])
+		if err != nil {
+			return nil, false, err
+		}
+		end := start
+		if matches[5] != "" {
+			if end, err = numeral2Int(matches[5]); err != nil {
+				return nil, false, err
+			}
+		}
+		if start > end {
+			return nil, false, nil
+		}
+		prefix := matches[1]
+		if keepRange && start < end {
+			street, streetKana := rangeStreet(prefix, start, end, matches[2], unit)
+			items = append([]streetListItem{{street, streetKana, start, end, unit}}, items...)
+			continue
+		}
+		if (end-start+1)+len(items) > limit {
+			return nil, false, nil
+		}
+		expanded := make([]streetListItem, 0, end-start+1)
+		for n := start; n <= end; n++ {
+			expanded = append(expanded, streetListItem{
+				street:     prefix + int2Numeral(n, matches[2]) + unit,
+				streetKana: fmt.Sprintf("%s%d%s", zen2hanMap[prefix], n, zen2hanMap[unit]),
+				from:       n,
+				to:         n,
+				unit:       unit,
+			})
+		}
+		items = append(expanded, items...)
+	}
+
+	return items, len(items) > 0, nil
+}
+
+// numberRow makes a row of the number n like `第１地割`.
+// If the range is kept, the number is also set to the street number fields.
+func (parts StreetParts) numberRow(p *JapanZipCode, prefix string, n int, like, unit string) *JapanZipCode {
+	row := parts.Row(p, prefix+int2Numeral(n, like)+unit, fmt.Sprintf("%s%d%s", zen2hanMap[prefix], n, zen2hanMap[unit]))
+	if parts.keepRange {
+		setStreetNumber(row, n, n, unit)
+	}
+	return row
+}
+
+// rangeStreet makes the street name of the range kept in one row like `１丁目～３丁目`.
+func rangeStreet(prefix string, from, to int, like, unit string) (string, string) {
+	street := prefix + int2Numeral(from, like) + unit + "～" + prefix + int2Numeral(to, like) + unit
+	streetKana := fmt.Sprintf("%s%d%s-%s%d%s", zen2hanMap[prefix], from, zen2hanMap[unit], zen2hanMap[prefix], to, zen2hanMap[unit])
+	return street, streetKana
+}
+
+func setStreetNumber(p *JapanZipCode, from, to int, unit string) *JapanZipCode {
+	p.StreetNumberFrom = from
+	p.StreetNumberTo = to
+	p.StreetNumberUnit = unit
+	return p
+}
@@ -0,0 +1,224 @@
+package gokenall
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_splitStreet(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *JapanZipCode
+		want StreetParts
+	}{
+		{"no parenthetical", &JapanZipCode{Street: "西本町", StreetKana: "ﾆｼﾎﾝﾏﾁ"}, StreetParts{Street: "西本町", StreetKana: "ﾆｼﾎﾝﾏﾁ"}},
+		{"parenthetical", &JapanZipCode{Street: "天神橋（７、８丁目）", StreetKana: "ﾃﾝｼﾞﾝﾊﾞｼ(7､8ﾁｮｳﾒ)"}, StreetParts{Street: "天神橋", StreetKana: "ﾃﾝｼﾞﾝﾊﾞｼ", Inner: "７、８丁目", InnerKana: "7､8ﾁｮｳﾒ"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStreet(tt.p); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStreet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeOptions_rules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []string
+		want    []string
+		wantErr bool
+	}{
+		{"reorder", []string{"floor", "clear"}, []string{"floor", "clear"}, false},
+		{"unknown", []string{"floor", "unknown"}, nil, true},
+		{"empty", []string{}, nil, true},
+		{"default", nil, StreetRuleNames(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normer, err := (&NormalizeOptions{Rules: tt.rules}).newNormalizer()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeOptions.newNormalizer() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			got := []string{}
+			for _, rule := range normer.rules {
+				got = append(got, rule.Name())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeOptions.newNormalizer() rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeOptions_rangeLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		want    int
+		wantErr bool
+	}{
+		{"default", 0, DefaultRangeLimit, false},
+		{"limit", 2, 2, false},
+		{"negative", -1, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normer, err := (&NormalizeOptions{RangeLimit: tt.limit}).newNormalizer()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeOptions.newNormalizer() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && normer.rangeLimit != tt.want {
+				t.Errorf("NormalizeOptions.newNormalizer() rangeLimit = %v, want %v", normer.rangeLimit, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterStreetRule(t *testing.T) {
+	rule := NewStreetRule("test-kita", func(p *JapanZipCode, parts StreetParts) ([]*JapanZipCode, bool, error) {
+		if parts.Inner != "北" {
+			return nil, false, nil
+		}
+		return []*JapanZipCode{parts.Row(p, "北区", "ｷﾀｸ")}, true, nil
+	})
+	if err := RegisterStreetRule(rule); err != nil {
+		t.Fatalf("RegisterStreetRule() error = %v", err)
+	}
+	defer unregisterStreetRule(rule.Name())
+	if err := RegisterStreetRule(rule); err == nil {
+		t.Errorf("RegisterStreetRule() error = nil, want error for duplicated name")
+	}
+
+	p, _ := parseCSV(`01104,"003  ","0030022","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼｼﾛｲｼｸ","ﾅﾝｺﾞｳﾄﾞｵﾘ(ｷﾀ)","北海道","札幌市白石区","南郷通（北）",1,0,0,0,0,0`, false)
+	want, _ := parseCSV(`01104,"003  ","0030022","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼｼﾛｲｼｸ","ﾅﾝｺﾞｳﾄﾞｵﾘｷﾀｸ","北海道","札幌市白石区","南郷通北区",1,0,0,0,0,0`, false)
+
+	normer, err := (&NormalizeOptions{Rules: []string{"test-kita", "name-list"}}).newNormalizer()
+	if err != nil {
+		t.Fatalf("NormalizeOptions.newNormalizer() error = %v", err)
+	}
+	normer.push(p)
+	if got := normer.pop(); !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizer.pop() = %v, want %v", got, want)
+	}
+}
+
+// unregisterStreetRule removes the rule registered by the test not to leak into the other tests.
+func unregisterStreetRule(name string) {
+	streetRulesMu.Lock()
+	defer streetRulesMu.Unlock()
+	rules := []StreetRule{}
+	for _, rule := range registeredStreetRules {
+		if rule.Name() != name {
+			rules = append(rules, rule)
+		}
+	}
+	registeredStreetRules = rules
+}
+
+func Test_normalizer_keepRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  []string
+	}{
+		{"range", `01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ(1-3ﾁｮｳﾒ)","北海道","札幌市南区","真駒内（１～３丁目）",0,0,1,0,0,0`, []string{
+			`01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ1ﾁｮｳﾒ-3ﾁｮｳﾒ","北海道","札幌市南区","真駒内１丁目～３丁目",0,0,1,0,0,0,1,3,"丁目"`,
+		}},
+		{"number list", `27127,"530  ","5300041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ(7､8ﾁｮｳﾒ)","大阪府","大阪市北区","天神橋（７、８丁目）",0,0,1,0,0,0`, []string{
+			`27127,"530  ","5300041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ7ﾁｮｳﾒ","大阪府","大阪市北区","天神橋７丁目",0,0,1,0,0,0,7,7,"丁目"`,
+			`27127,"530  ","5300041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ8ﾁｮｳﾒ","大阪府","大阪市北区","天神橋８丁目",0,0,1,0,0,0,8,8,"丁目"`,
+		}},
+		{"chiwari", `03302,"02844","0284301","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｴｶﾘ(ﾀﾞｲ1ﾁﾜﾘ-ﾀﾞｲ3ﾁﾜﾘ)","岩手県","岩手郡葛巻町","江刈（第１地割～第３地割）",1,1,0,0,0,0`, []string{
+			`03302,"02844","0284301","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｴｶﾘﾀﾞｲ1ﾁﾜﾘ-ﾀﾞｲ3ﾁﾜﾘ","岩手県","岩手郡葛巻町","江刈第１地割～第３地割",1,1,0,0,0,0,1,3,"地割"`,
+		}},
+		{"mixed list", `01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ(1-3ﾁｮｳﾒ､5ﾁｮｳﾒ)","北海道","札幌市南区","真駒内（１～３丁目、５丁目）",0,0,1,0,0,0`, []string{
+			`01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ1ﾁｮｳﾒ-3ﾁｮｳﾒ","北海道","札幌市南区","真駒内１丁目～３丁目",0,0,1,0,0,0,1,3,"丁目"`,
+			`01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ5ﾁｮｳﾒ","北海道","札幌市南区","真駒内５丁目",0,0,1,0,0,0,5,5,"丁目"`,
+		}},
+		{"over limit", `01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ(1-300ﾊﾞﾝﾁ)","北海道","札幌市南区","真駒内（１～３００番地）",0,0,1,0,0,0`, []string{
+			`01106,"005  ","0050011","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾐﾅﾐｸ","ﾏｺﾏﾅｲ1ﾊﾞﾝﾁ-300ﾊﾞﾝﾁ","北海道","札幌市南区","真駒内１番地～３００番地",0,0,1,0,0,0,1,300,"番地"`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := parseCSV(tt.before, false)
+			normer := newNormalizer()
+			normer.keepRange = true
+			normer.push(p)
+			for _, after := range tt.after {
+				want, err := parseCSVWithOption(after, NormalizeRange)
+				if err != nil {
+					t.Fatalf("parseCSVWithOption() error = %v", err)
+				}
+				if got := normer.pop(); !reflect.DeepEqual(got, want) {
+					t.Errorf("normalizer.pop() = %v, want %v", got, want)
+				}
+			}
+			if normer.canPop() {
+				t.Errorf("normalizer.canPop() = true, want false")
+			}
+		})
+	}
+}
+
+func Test_applyChiwariRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		keepRange bool
+		before    string
+		want      []string
+		ok        bool
+	}{
+		{"exclusion", false, `03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷ(ﾀﾞｲ40ﾁﾜﾘ-ﾀﾞｲ41ﾁﾜﾘ<1ﾊﾞﾝﾁｦﾉｿﾞｸ>)","岩手県","岩手郡葛巻町","葛巻（第４０地割～第４１地割「１番地を除く」）",1,1,0,0,0,0`, []string{
+			`03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷﾀﾞｲ40ﾁﾜﾘ","岩手県","岩手郡葛巻町","葛巻第４０地割",1,1,0,0,0,0,"","",`,
+			`03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷﾀﾞｲ41ﾁﾜﾘ","岩手県","岩手郡葛巻町","葛巻第４１地割",1,1,0,0,0,0,"１番地を除く","1ﾊﾞﾝﾁｦﾉｿﾞｸ",exclusion`,
+		}, true},
+		{"not exclusion", false, `03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷ(ﾀﾞｲ40ﾁﾜﾘ<ﾐﾅﾐ>)","岩手県","岩手郡葛巻町","葛巻（第４０地割「南」）",1,1,0,0,0,0`, nil, false},
+		{"kana mismatch", false, `03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷ(ﾀﾞｲ40ﾁﾜﾘ)","岩手県","岩手郡葛巻町","葛巻（第４０地割「１番地を除く」）",1,1,0,0,0,0`, nil, false},
+		{"both ends", false, `03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷ(ﾀﾞｲ1ﾁﾜﾘ<1ﾊﾞﾝﾁｦﾉｿﾞｸ>-ﾀﾞｲ2ﾁﾜﾘ<2ﾊﾞﾝﾁｦﾉｿﾞｸ>)","岩手県","岩手郡葛巻町","葛巻（第一地割「１番地を除く」～第二地割「２番地を除く」）",1,1,0,0,0,0`, []string{
+			`03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷﾀﾞｲ1ﾁﾜﾘ","岩手県","岩手郡葛巻町","葛巻第一地割",1,1,0,0,0,0,"１番地を除く","1ﾊﾞﾝﾁｦﾉｿﾞｸ",exclusion`,
+			`03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷﾀﾞｲ2ﾁﾜﾘ","岩手県","岩手郡葛巻町","葛巻第二地割",1,1,0,0,0,0,"２番地を除く","2ﾊﾞﾝﾁｦﾉｿﾞｸ",exclusion`,
+		}, true},
+		{"both ends kept in range", true, `03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷ(ﾀﾞｲ1ﾁﾜﾘ<1ﾊﾞﾝﾁｦﾉｿﾞｸ>-ﾀﾞｲ3ﾁﾜﾘ<2ﾊﾞﾝﾁｦﾉｿﾞｸ>)","岩手県","岩手郡葛巻町","葛巻（第一地割「１番地を除く」～第三地割「２番地を除く」）",1,1,0,0,0,0`, []string{
+			`03302,"02841","0284121","ｲﾜﾃｹﾝ","ｲﾜﾃｸﾞﾝｸｽﾞﾏｷﾏﾁ","ｸｽﾞﾏｷﾀﾞｲ1ﾁﾜﾘ-ﾀﾞｲ3ﾁﾜﾘ","岩手県","岩手郡葛巻町","葛巻第一地割～第三地割",1,1,0,0,0,0,"第一地割「１番地を除く」、第三地割「２番地を除く」","ﾀﾞｲ1ﾁﾜﾘ<1ﾊﾞﾝﾁｦﾉｿﾞｸ>､ﾀﾞｲ3ﾁﾜﾘ<2ﾊﾞﾝﾁｦﾉｿﾞｸ>",exclusion,1,3,"地割"`,
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := parseCSV(tt.before, false)
+			parts := splitStreet(p)
+			parts.rangeLimit = DefaultRangeLimit
+			parts.keepRange = tt.keepRange
+			got, ok, err := applyChiwariRule(p, parts)
+			if err != nil {
+				t.Fatalf("applyChiwariRule() error = %v", err)
+			}
+			if ok != tt.ok {
+				t.Fatalf("applyChiwariRule() ok = %v, want %v", ok, tt.ok)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("applyChiwariRule() = %d rows, want %d", len(got), len(tt.want))
+			}
+			for i, after := range tt.want {
+				option := NormalizeStreetNote
+				if tt.keepRange {
+					option |= NormalizeRange
+				}
+				want, err := parseCSVWithOption(after, option)
+				if err != nil {
+					t.Fatalf("parseCSVWithOption() error = %v", err)
+				}
+				if !reflect.DeepEqual(got[i], want) {
+					t.Errorf("applyChiwariRule()[%d] = %v, want %v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package gokenall
+
+import (
+	"strings"
+	"unicode"
+)
+
+// variantMap folds the character variants (異体字) into the common character.
+var variantMap = map[rune]rune{
+	'﨑': '崎', '嵜': '崎', '碕': '崎',
+	'髙': '高',
+	'德': '徳',
+	'濵': '浜', '濱': '浜',
+	'邊': '辺', '邉': '辺',
+	'齋': '斎', '齊': '斉',
+	'櫻': '桜',
+	'國': '国',
+	'澤': '沢',
+	'廣': '広',
+	'嶋': '島', '嶌': '島',
+	'舘': '館',
+	'冨': '富',
+	'籔': '薮', '藪': '薮',
+	'龍':      '竜',
+	'曾':      '曽',
+	'檜':      '桧',
+	'槇':      '槙',
+	'\uFA10': '塚', // 塚 (CJK互換漢字)
+	'﨔':      '欅',
+	'惠':      '恵',
+	'眞':      '真',
+	'淵':      '渕',
+	'瀨':      '瀬',
+	'萬':      '万',
+	'彌':      '弥',
+	'驛':      '駅',
+	'縣':      '県',
+	'之':      'ノ',
+	'ヶ':      'ケ', 'ヵ': 'ケ', 'ゖ': 'ケ', 'ゕ': 'ケ',
+	'‐': '-', '‑': '-', '‒': '-', '–': '-', '—': '-', '―': '-', '−': '-',
+}
+
+// contextVariantMap folds the kana used as a particle between kanji like `霞が関` and `虎の門`.
+var contextVariantMap = map[rune]rune{
+	'が': 'ケ', 'ガ': 'ケ',
+	'の': 'ノ',
+}
+
+// SearchKey makes the key to match the texts written in different ways like `霞ヶ関` and `霞が関`.
+// The width is folded, spaces are removed and the character variants like `﨑`, `髙` and `之` are folded.
+// Use it for both of the texts in ken_all and the texts typed by users.
+func SearchKey(s string) string {
+	runes := []rune(foldWidth(s))
+	key := make([]rune, 0, len(runes))
+	for i, r := range runes {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		if v, ok := variantMap[r]; ok {
+			r = v
+		} else if v, ok := contextVariantMap[r]; ok && i > 0 && i+1 < len(runes) && isKanji(runes[i-1]) && isKanji(runes[i+1]) {
+			r = v
+		}
+		key = append(key, r)
+	}
+	return strings.ToUpper(string(key))
+}
+
+func isKanji(r rune) bool {
+	return unicode.Is(unicode.Han, r) || r == '々'
+}
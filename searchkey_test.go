@@ -0,0 +1,48 @@
+package gokenall
+
+import "testing"
+
+func TestSearchKey(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"same", args{"西新宿"}, "西新宿"},
+		{"variant", args{"髙﨑"}, "高崎"},
+		{"small ke", args{"霞ヶ関"}, "霞ケ関"},
+		{"ga between kanji", args{"霞が関"}, "霞ケ関"},
+		{"ga in kana", args{"ながの"}, "ながの"},
+		{"no", args{"虎之門"}, "虎ノ門"},
+		{"no between kanji", args{"虎の門"}, "虎ノ門"},
+		{"width", args{"ｱﾍﾞﾉ１丁目"}, "アベノ1丁目"},
+		{"spaces", args{"新宿 センター　ビル"}, "新宿センタービル"},
+		{"dash", args{"１−２"}, "1-2"},
+		{"ascii case", args{"ＪＲタワー"}, "JRタワー"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SearchKey(tt.args.s); got != tt.want {
+				t.Errorf("SearchKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchKey_match(t *testing.T) {
+	pairs := [][2]string{
+		{"霞ヶ関", "霞が関"},
+		{"霞ケ関", "霞ヶ関"},
+		{"﨑", "崎"},
+		{"髙島屋", "高島屋"},
+		{"虎ノ門", "虎之門"},
+	}
+	for _, pair := range pairs {
+		if SearchKey(pair[0]) != SearchKey(pair[1]) {
+			t.Errorf("SearchKey(%v) = %v, SearchKey(%v) = %v, want same", pair[0], SearchKey(pair[0]), pair[1], SearchKey(pair[1]))
+		}
+	}
+}
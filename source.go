@@ -0,0 +1,110 @@
+package gokenall
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Source is where the archives like ken_all.zip are read from.
+// Use it to read the archives from an internal mirror or a local disk instead of japanpost website.
+type Source interface {
+	// Open opens the archive named name like `ken_all.zip`.
+	Open(name string) (io.ReadCloser, error)
+}
+
+// HTTPSource is the archives on the web.
+// The download by DownloadWithOptions from HTTPSource is cached and retried by DownloadOptions.
+type HTTPSource struct {
+	BaseURL string       // アーカイブがあるディレクトリの URL（空の場合は日本郵便の ken_all.zip があるディレクトリ。/ で終わらない場合はアーカイブそのものの URL）
+	Client  *http.Client // HTTP クライアント（nil の場合は http.DefaultClient）
+}
+
+// URL returns the url of the archive named name.
+func (src *HTTPSource) URL(name string) string {
+	base := src.BaseURL
+	if base == "" {
+		base = kenAllFileURL[:strings.LastIndex(kenAllFileURL, "/")+1]
+	}
+	if !strings.HasSuffix(base, "/") {
+		return base
+	}
+	return base + name
+}
+
+// Open implements Source.
+func (src *HTTPSource) Open(name string) (io.ReadCloser, error) {
+	client := src.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	u := src.URL(name)
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download url: %s", u)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("failed to download url: %s: %s", u, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// DirSource is the archives in the local directory.
+type DirSource string
+
+// Open implements Source.
+func (src DirSource) Open(name string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(string(src), filepath.FromSlash(name)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open archive: %s", name)
+	}
+	return f, nil
+}
+
+// ZipFileSource is the local archive file. Open returns the file whatever the name is.
+type ZipFileSource string
+
+// Open implements Source.
+func (src ZipFileSource) Open(name string) (io.ReadCloser, error) {
+	f, err := os.Open(string(src))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open archive: %s", string(src))
+	}
+	return f, nil
+}
+
+// ParseSource returns the Source of s.
+// s is a url like `https://mirror.example.com/ken_all/` or `file:///mnt/mirror`, or a local path.
+// The local path is ZipFileSource if it is a file, otherwise DirSource.
+// The http(s) url is HTTPSource. See HTTPSource.BaseURL for the url not ending with `/`.
+func ParseSource(s string) (Source, error) {
+	u, err := url.Parse(s)
+	if err != nil || len(u.Scheme) <= 1 {
+		// not a url, or a windows path like `C:\mirror`
+		return localSource(s), nil
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return &HTTPSource{BaseURL: s}, nil
+	case "file":
+		p := u.Path
+		if u.Host != "" && u.Host != "localhost" {
+			p = "//" + u.Host + p
+		}
+		return localSource(filepath.FromSlash(p)), nil
+	}
+	return nil, errors.Errorf("unsupported source: %s", s)
+}
+
+func localSource(p string) Source {
+	if info, err := os.Stat(p); err == nil && !info.IsDir() || strings.EqualFold(filepath.Ext(p), ".zip") {
+		return ZipFileSource(p)
+	}
+	return DirSource(p)
+}
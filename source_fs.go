@@ -0,0 +1,25 @@
+//go:build go1.16
+// +build go1.16
+
+package gokenall
+
+import (
+	"io"
+	"io/fs"
+
+	"github.com/pkg/errors"
+)
+
+// FSSource is the archives in fsys like embed.FS.
+type FSSource struct {
+	FS fs.FS
+}
+
+// Open implements Source.
+func (src FSSource) Open(name string) (io.ReadCloser, error) {
+	f, err := src.FS.Open(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open archive: %s", name)
+	}
+	return f, nil
+}
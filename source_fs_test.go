@@ -0,0 +1,25 @@
+//go:build go1.16
+// +build go1.16
+
+package gokenall
+
+import (
+	"io/ioutil"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"mirror/ken_all.zip": &fstest.MapFile{Data: storedZip(t, "KEN_ALL.CSV", "KEN_ALL")},
+	}
+	r := OpenCSV(&DownloadOptions{Source: FSSource{FS: fsys}, Archive: "mirror/ken_all.zip"})
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("OpenCSV() error = %+v", err)
+	}
+	if string(b) != "KEN_ALL" {
+		t.Errorf("OpenCSV() = %q, want %q", b, "KEN_ALL")
+	}
+}
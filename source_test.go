@@ -0,0 +1,98 @@
+package gokenall
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gokenall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	archive := filepath.Join(dir, "ken_all.zip")
+	if err := ioutil.WriteFile(archive, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		s       string
+		want    Source
+		wantErr bool
+	}{
+		{"https://mirror.example.com/ken_all/", &HTTPSource{BaseURL: "https://mirror.example.com/ken_all/"}, false},
+		{"file://" + filepath.ToSlash(dir), DirSource(dir), false},
+		{"file://" + filepath.ToSlash(archive), ZipFileSource(archive), false},
+		{dir, DirSource(dir), false},
+		{archive, ZipFileSource(archive), false},
+		{"ftp://mirror.example.com/", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseSource(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSource() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPSource_URL(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+	}{
+		{"", kenAllFileURL},
+		{"https://mirror.example.com/ken_all/", "https://mirror.example.com/ken_all/ken_all.zip"},
+		{"https://mirror.example.com/latest.zip", "https://mirror.example.com/latest.zip"},
+	}
+	for _, tt := range tests {
+		if got := (&HTTPSource{BaseURL: tt.baseURL}).URL("ken_all.zip"); got != tt.want {
+			t.Errorf("URL() with %q = %q, want %q", tt.baseURL, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadWithOptions_source(t *testing.T) {
+	archive := storedZip(t, "ADD_1909.CSV", "ADD_1909")
+	dir, err := ioutil.TempDir("", "gokenall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "add_1909.zip"), archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer ts.Close()
+
+	for _, src := range []Source{
+		DirSource(dir),
+		ZipFileSource(filepath.Join(dir, "add_1909.zip")),
+		&HTTPSource{BaseURL: ts.URL + "/"},
+	} {
+		var b bytes.Buffer
+		if err := DownloadWithOptions(&b, &DownloadOptions{Source: src, Archive: "add_1909.zip", Extract: true}); err != nil {
+			t.Errorf("DownloadWithOptions(%#v) error = %+v", src, err)
+			continue
+		}
+		if b.String() != "ADD_1909" {
+			t.Errorf("DownloadWithOptions(%#v) = %q, want %q", src, b.String(), "ADD_1909")
+		}
+	}
+
+	if err := DownloadWithOptions(ioutil.Discard, &DownloadOptions{Source: DirSource(dir)}); err == nil {
+		t.Errorf("DownloadWithOptions() error = nil for missing archive")
+	}
+}
@@ -0,0 +1,172 @@
+package gokenall
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SyncState is the state of the last sync saved in the state file.
+type SyncState struct {
+	Date         time.Time `json:"date"`          // 取り込んだデータのサイト上の更新日（不明な場合や Source を指定した場合はゼロ値）
+	SourceSHA256 string    `json:"source_sha256"` // 解凍した csv の SHA-256
+	OutputSHA256 string    `json:"output_sha256"` // 出力ファイルの SHA-256
+	SyncedAt     time.Time `json:"synced_at"`     // 同期した日時
+}
+
+// SyncOptions is the options at sync.
+type SyncOptions struct {
+	State     string            // 状態ファイルのパス（空の場合は保存しない）
+	Download  *DownloadOptions  // ダウンロードの設定（nil の場合はデフォルト。Extract は常に true）
+	Normalize *NormalizeOptions // 加工の設定（nil の場合はデフォルト）
+	Force     bool              // 更新がなくても出力する
+}
+
+// LoadSyncState reads the state file. If the file does not exist, returns the zero value.
+func LoadSyncState(name string) (*SyncState, error) {
+	state := &SyncState{}
+	b, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read state file: %s", name)
+	}
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse state file: %s", name)
+	}
+	return state, nil
+}
+
+// Save writes the state to the file name atomically.
+func (state *SyncState) Save(name string) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal state")
+	}
+	return writeFileAtomic(name, func(w io.Writer) error {
+		if _, err := w.Write(append(b, '\n')); err != nil {
+			return errors.Wrapf(err, "failed to write state file: %s", name)
+		}
+		return nil
+	})
+}
+
+// Sync downloads, normalizes and saves the file name when the data is updated since the last sync in opts.State.
+// For japanpost website, the update is checked by the date on the website before downloading,
+// and for every source, by the SHA-256 of the downloaded csv.
+// If opts.Download.CacheDir is set, the update is also checked by the cache before normalizing.
+// The file is written atomically, and the state file is updated only on success.
+// If not updated, returns ErrNotModified and the file is kept. The file is always written if it does not exist.
+func Sync(name string, opts *SyncOptions) (*SyncState, error) {
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+	last := &SyncState{}
+	if opts.State != "" {
+		var err error
+		if last, err = LoadSyncState(opts.State); err != nil {
+			return nil, err
+		}
+	}
+
+	// write again if the file is removed after the last sync
+	_, err := os.Stat(name)
+	force := opts.Force || os.IsNotExist(err)
+
+	download := DownloadOptions{}
+	if opts.Download != nil {
+		download = *opts.Download
+	}
+	download.Extract = true
+
+	state := &SyncState{}
+	if download.Source == nil && download.URL == "" && download.Archive == "" {
+		info, err := FetchReleaseInfo("")
+		if err != nil {
+			return nil, err
+		}
+		state.Date = info.Date
+		if !force && sameRelease(last.Date, state.Date) {
+			return last, ErrNotModified
+		}
+	}
+
+	// the cache is updated before normalizing to know it is not modified,
+	// and the archive is read from the cache, which is there even if not modified
+	if _, isHTTP := download.Source.(*HTTPSource); download.CacheDir != "" && (download.Source == nil || isHTTP) {
+		err := DownloadWithOptions(ioutil.Discard, &download)
+		if err == ErrNotModified && !force {
+			// the cached archive is the release of the date, so the date is recorded not to download again
+			if !state.Date.IsZero() {
+				last.Date = state.Date
+				if opts.State != "" {
+					if err := last.Save(opts.State); err != nil {
+						return nil, err
+					}
+				}
+			}
+			return last, ErrNotModified
+		}
+		if err != nil && err != ErrNotModified {
+			return nil, err
+		}
+		download.Source = ZipFileSource(cacheArchivePath(download.CacheDir, download.archiveName()))
+		if download.URL != "" {
+			download.Archive = path.Base(download.URL)
+		}
+		download.URL = ""
+		download.CacheDir = ""
+		download.Retry = nil
+		download.Progress = nil
+	}
+
+	source, output := sha256.New(), sha256.New()
+	var changed bool
+	err = writeFileAtomic(name, func(w io.Writer) error {
+		r := OpenCSV(&download)
+		defer r.Close()
+		if err := NormalizeWithOptions(io.TeeReader(r, source), io.MultiWriter(w, output), opts.Normalize); err != nil {
+			return err
+		}
+		state.SourceSHA256 = hex.EncodeToString(source.Sum(nil))
+		state.OutputSHA256 = hex.EncodeToString(output.Sum(nil))
+		changed = force || state.SourceSHA256 != last.SourceSHA256 || state.OutputSHA256 != last.OutputSHA256
+		if !changed {
+			// keep the file as it is
+			return ErrNotModified
+		}
+		return nil
+	})
+	if err != nil && errors.Cause(err) != ErrNotModified {
+		return nil, err
+	}
+
+	state.SyncedAt = time.Now()
+	if !changed {
+		// the date is updated not to download again for the same data
+		state.SyncedAt = last.SyncedAt
+	}
+	if opts.State != "" {
+		if err := state.Save(opts.State); err != nil {
+			return nil, err
+		}
+	}
+	if !changed {
+		return state, ErrNotModified
+	}
+	return state, nil
+}
+
+// sameRelease reports whether the release of date is already synced at last.
+// The zero date is unknown, and the update is checked by the SHA-256 instead.
+func sameRelease(last, date time.Time) bool {
+	return !last.IsZero() && !date.IsZero() && !last.Before(date)
+}
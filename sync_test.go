@@ -0,0 +1,246 @@
+package gokenall
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestSync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gokenall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mirror := filepath.Join(dir, "mirror")
+	if err := os.Mkdir(mirror, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeArchive := func(csv string) {
+		csv, _ = japanese.ShiftJIS.NewEncoder().String(csv)
+		if err := ioutil.WriteFile(filepath.Join(mirror, "ken_all.zip"), storedZip(t, "KEN_ALL.CSV", csv), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	row1 := "01101,\"060  \",\"0600000\",\"ﾎｯｶｲﾄﾞｳ\",\"ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ\",\"ｲｶﾆｹｲｻｲｶﾞﾅｲﾊﾞｱｲ\",\"北海道\",\"札幌市中央区\",\"以下に掲載がない場合\",0,0,0,0,0,0\r\n"
+	row2 := "01224,\"066  \",\"0660005\",\"ﾎｯｶｲﾄﾞｳ\",\"ﾁﾄｾｼ\",\"ｷｮｳﾜ\",\"北海道\",\"千歳市\",\"協和\",1,0,0,0,0,0\r\n"
+
+	name := filepath.Join(dir, "ken_all.csv")
+	statePath := filepath.Join(dir, "state.json")
+	opts := &SyncOptions{
+		State:    statePath,
+		Download: &DownloadOptions{Source: DirSource(mirror)},
+	}
+
+	writeArchive(row1)
+	state, err := Sync(name, opts)
+	if err != nil {
+		t.Fatalf("Sync() error = %+v", err)
+	}
+	first, _ := ioutil.ReadFile(name)
+	if want := "01101,\"060\",\"0600000\",\"ホッカイドウ\",\"サッポロシチュウオウク\",\"\",\"北海道\",\"札幌市中央区\",\"\",0,0,0,0,0,0"; string(first) != want {
+		t.Errorf("Sync() wrote %q, want %q", first, want)
+	}
+	saved, err := LoadSyncState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.SourceSHA256 != state.SourceSHA256 || saved.OutputSHA256 == "" {
+		t.Errorf("state file = %+v, want %+v", saved, state)
+	}
+
+	if _, err := Sync(name, opts); err != ErrNotModified {
+		t.Errorf("Sync() without update error = %v, want ErrNotModified", err)
+	}
+
+	writeArchive(row1 + row2)
+	if _, err := Sync(name, opts); err != nil {
+		t.Fatalf("Sync() after update error = %+v", err)
+	}
+	if second, _ := ioutil.ReadFile(name); len(second) <= len(first) {
+		t.Errorf("Sync() after update did not write the new rows: %q", second)
+	}
+
+	// broken archive keeps the file and the state
+	updated, _ := ioutil.ReadFile(name)
+	updatedState, _ := ioutil.ReadFile(statePath)
+	if err := ioutil.WriteFile(filepath.Join(mirror, "ken_all.zip"), []byte("broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sync(name, opts); err == nil || err == ErrNotModified {
+		t.Errorf("Sync() with broken archive error = %v, want error", err)
+	}
+	if b, _ := ioutil.ReadFile(name); string(b) != string(updated) {
+		t.Errorf("Sync() with broken archive overwrote the file")
+	}
+	if b, _ := ioutil.ReadFile(statePath); string(b) != string(updatedState) {
+		t.Errorf("Sync() with broken archive overwrote the state")
+	}
+
+	// removed file is written again
+	os.Remove(name)
+	writeArchive(row1 + row2)
+	if _, err := Sync(name, opts); err != nil {
+		t.Fatalf("Sync() after remove error = %+v", err)
+	}
+	if b, _ := ioutil.ReadFile(name); string(b) != string(updated) {
+		t.Errorf("Sync() after remove wrote %q, want %q", b, updated)
+	}
+}
+
+func TestSync_cache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gokenall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	csv, _ := japanese.ShiftJIS.NewEncoder().String("01101,\"060  \",\"0600000\",\"ﾎｯｶｲﾄﾞｳ\",\"ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ\",\"ｲｶﾆｹｲｻｲｶﾞﾅｲﾊﾞｱｲ\",\"北海道\",\"札幌市中央区\",\"以下に掲載がない場合\",0,0,0,0,0,0\r\n")
+	body := storedZip(t, "KEN_ALL.CSV", csv)
+	var downloads int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	name := filepath.Join(dir, "ken_all.csv")
+	opts := &SyncOptions{
+		State:    filepath.Join(dir, "state.json"),
+		Download: &DownloadOptions{Source: &HTTPSource{BaseURL: srv.URL + "/"}, CacheDir: filepath.Join(dir, "cache")},
+	}
+
+	if _, err := Sync(name, opts); err != nil {
+		t.Fatalf("Sync() error = %+v", err)
+	}
+	first, _ := ioutil.ReadFile(name)
+
+	if _, err := Sync(name, opts); err != ErrNotModified {
+		t.Errorf("Sync() with 304 error = %v, want ErrNotModified", err)
+	}
+
+	// removed file is written again from the cache
+	os.Remove(name)
+	if _, err := Sync(name, opts); err != nil {
+		t.Fatalf("Sync() after remove error = %+v", err)
+	}
+	if b, _ := ioutil.ReadFile(name); string(b) != string(first) {
+		t.Errorf("Sync() after remove wrote %q, want %q", b, first)
+	}
+	if downloads != 1 {
+		t.Errorf("Sync() downloaded %d times, want 1", downloads)
+	}
+}
+
+// redirectTransport sends all the requests to the test server.
+type redirectTransport string
+
+func (server redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u, err := url.Parse(string(server))
+	if err != nil {
+		return nil, err
+	}
+	req.URL.Scheme, req.URL.Host = u.Scheme, u.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestSync_cacheDate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gokenall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	csv, _ := japanese.ShiftJIS.NewEncoder().String("01101,\"060  \",\"0600000\",\"ﾎｯｶｲﾄﾞｳ\",\"ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ\",\"ｲｶﾆｹｲｻｲｶﾞﾅｲﾊﾞｱｲ\",\"北海道\",\"札幌市中央区\",\"以下に掲載がない場合\",0,0,0,0,0,0\r\n")
+	body := storedZip(t, "KEN_ALL.CSV", csv)
+	date := "2019年9月30日"
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path.Ext(r.URL.Path) == ".html" {
+			w.Write([]byte("<html><body>" + date + "更新</body></html>"))
+			return
+		}
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	// the website is replaced by the test server
+	client := &http.Client{Transport: redirectTransport(srv.URL)}
+	defaultClient := http.DefaultClient
+	http.DefaultClient = client
+	defer func() { http.DefaultClient = defaultClient }()
+
+	name := filepath.Join(dir, "ken_all.csv")
+	opts := &SyncOptions{
+		State:    filepath.Join(dir, "state.json"),
+		Download: &DownloadOptions{CacheDir: filepath.Join(dir, "cache")},
+	}
+	if _, err := Sync(name, opts); err != nil {
+		t.Fatalf("Sync() error = %+v", err)
+	}
+
+	// the date is updated but the archive is not modified
+	date = "2019年10月31日"
+	if _, err := Sync(name, opts); err != ErrNotModified {
+		t.Errorf("Sync() with 304 error = %v, want ErrNotModified", err)
+	}
+	state, err := LoadSyncState(opts.State)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2019, 10, 31, 0, 0, 0, 0, time.UTC); !state.Date.Equal(want) {
+		t.Errorf("Sync() with 304 saved date %v, want %v", state.Date, want)
+	}
+
+	// the recorded date is checked before downloading
+	if _, err := Sync(name, opts); err != ErrNotModified {
+		t.Errorf("Sync() after 304 error = %v, want ErrNotModified", err)
+	}
+	if requests != 2 {
+		t.Errorf("Sync() requested the archive %d times, want 2", requests)
+	}
+}
+
+func Test_sameRelease(t *testing.T) {
+	day := time.Date(2019, 9, 30, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		last time.Time
+		date time.Time
+		want bool
+	}{
+		{"same", day, day, true},
+		{"updated", day, day.AddDate(0, 1, 0), false},
+		{"older", day, day.AddDate(0, -1, 0), true},
+		{"never synced", time.Time{}, day, false},
+		{"unknown date", day, time.Time{}, false},
+		{"both unknown", time.Time{}, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameRelease(tt.last, tt.date); got != tt.want {
+				t.Errorf("sameRelease() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package gokenall
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// mergeRuleName is recorded in the trace when multiple lines are merged into one.
+	mergeRuleName = "merge"
+	// unhandledRuleName is recorded in the trace when no rule handles the parenthetical and it is just removed.
+	unhandledRuleName = "unhandled"
+	// traceSeparator separates the values in a trace column.
+	traceSeparator = ";"
+)
+
+// Trace is the provenance of a normalized row.
+type Trace struct {
+	SourceLines    []int    `json:"source_lines"`    // 元データの行番号（複数行をマージした場合は全ての行）
+	Rules          []string `json:"rules"`           // 適用した加工の名前（複数行のマージは merge、どのルールにも当てはまらなかった場合は unhandled）
+	OriginalStreet string   `json:"original_street"` // 加工前の町域名
+}
+
+func (trace *Trace) withRule(name string) *Trace {
+	if trace == nil {
+		return nil
+	}
+	t := *trace
+	t.Rules = append(append([]string{}, trace.Rules...), name)
+	return &t
+}
+
+func (trace *Trace) revertArray() []string {
+	if trace == nil {
+		return []string{"", "", ""}
+	}
+	lines := make([]string, len(trace.SourceLines))
+	for i, line := range trace.SourceLines {
+		lines[i] = strconv.Itoa(line)
+	}
+	return []string{
+		strings.Join(lines, traceSeparator),
+		strings.Join(trace.Rules, traceSeparator),
+		trace.OriginalStreet,
+	}
+}
+
+func parseTraceArray(cols []string) (*Trace, error) {
+	if cols[0] == "" && cols[1] == "" && cols[2] == "" {
+		return nil, nil
+	}
+	trace := &Trace{
+		SourceLines:    []int{},
+		Rules:          []string{},
+		OriginalStreet: cols[2],
+	}
+	if cols[0] != "" {
+		for _, s := range strings.Split(cols[0], traceSeparator) {
+			line, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse source line: %s", cols[0])
+			}
+			trace.SourceLines = append(trace.SourceLines, line)
+		}
+	}
+	if cols[1] != "" {
+		trace.Rules = strings.Split(cols[1], traceSeparator)
+	}
+	return trace, nil
+}
@@ -0,0 +1,77 @@
+package gokenall
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func Test_normalizer_trace(t *testing.T) {
+	inputs := []string{
+		`01224,"066  ","0660005","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","ｷｮｳﾜ(88-2､271-10､343-2､404-1､427-","北海道","千歳市","協和（８８－２、２７１－１０、３４３－２、４０４－１、４２７－",1,0,0,0,0,0`,
+		`01224,"066  ","0660005","ﾎｯｶｲﾄﾞｳ","ﾁﾄｾｼ","3ﾊﾞﾝﾁ)","北海道","千歳市","３番地）",1,0,0,0,0,0`,
+		`27127,"531  ","5310041","ｵｵｻｶﾌ","ｵｵｻｶｼｷﾀｸ","ﾃﾝｼﾞﾝﾊﾞｼ(7､8ﾁｮｳﾒ)","大阪府","大阪市北区","天神橋（７、８丁目）",1,0,1,0,0,0`,
+		`40206,"826  ","8260024","ﾌｸｵｶｹﾝ","ﾀｶﾞﾜｼ","ﾆｼﾎﾝﾏﾁ","福岡県","田川市","西本町",0,0,0,0,0,0`,
+	}
+	want := []*Trace{
+		{[]int{1, 2}, []string{"merge", "unhandled"}, "協和（８８－２、２７１－１０、３４３－２、４０４－１、４２７－３番地）"},
+		{[]int{3}, []string{"number-list"}, "天神橋（７、８丁目）"},
+		{[]int{3}, []string{"number-list"}, "天神橋（７、８丁目）"},
+		{[]int{4}, nil, "西本町"},
+	}
+
+	normer := newNormalizer()
+	normer.trace = true
+	for _, input := range inputs {
+		p, _ := parseCSV(input, false)
+		normer.push(p)
+	}
+	for _, w := range want {
+		if got := normer.pop(); !reflect.DeepEqual(got.Trace, w) {
+			t.Errorf("normalizer.pop().Trace = %v, want %v", got.Trace, w)
+		}
+	}
+}
+
+func TestTrace_revertArray(t *testing.T) {
+	tests := []struct {
+		name  string
+		trace *Trace
+		want  []string
+	}{
+		{"nil", nil, []string{"", "", ""}},
+		{"trace", &Trace{[]int{1, 2}, []string{"merge", "range"}, "天神橋（１～６丁目）"}, []string{"1;2", "merge;range", "天神橋（１～６丁目）"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.trace.revertArray()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Trace.revertArray() = %v, want %v", got, tt.want)
+			}
+			if parsed, _ := parseTraceArray(got); tt.trace != nil && !reflect.DeepEqual(parsed, tt.trace) {
+				t.Errorf("parseTraceArray() = %v, want %v", parsed, tt.trace)
+			}
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	input, _ := japanese.ShiftJIS.NewEncoder().String(strings.Join([]string{
+		`01101,"060  ","0600000","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","ｲｶﾆｹｲｻｲｶﾞﾅｲﾊﾞｱｲ","北海道","札幌市中央区","以下に掲載がない場合",0,0,0,0,0,0`,
+		`01101,"064  ","0640941","ﾎｯｶｲﾄﾞｳ","ｻｯﾎﾟﾛｼﾁｭｳｵｳｸ","ｱｻﾋｶﾞｵｶ","北海道","札幌市中央区","旭ケ丘",0,0,1,0,0,0`,
+	}, "\n"))
+
+	got, err := Explain(strings.NewReader(input), "0600000", DefaultNormalizeOption)
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("len(Explain()) = %d, want 1", len(got))
+	}
+	want := &Trace{[]int{1}, []string{"clear"}, "以下に掲載がない場合"}
+	if !reflect.DeepEqual(got[0].Trace, want) {
+		t.Errorf("Explain()[0].Trace = %v, want %v", got[0].Trace, want)
+	}
+}